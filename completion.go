@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// completeCommand is the hidden subcommand which the completion scripts call
+// to get the CronJob names for the word being completed.
+const completeCommand = "__complete"
+
+var completionScripts = map[string]string{
+	"bash": `_%[1]s() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	if [[ "$cur" == -* ]]; then
+		return
+	fi
+	COMPREPLY=($(%[1]s %[2]s "$cur" 2>/dev/null))
+}
+complete -F _%[1]s %[1]s
+`,
+	"zsh": `_%[1]s() {
+	local -a candidates
+	candidates=(${(f)"$(%[1]s %[2]s "${words[CURRENT]}" 2>/dev/null)"})
+	compadd -a candidates
+}
+compdef _%[1]s %[1]s
+`,
+	"fish": `complete -c %[1]s -f -a '(%[1]s %[2]s (commandline -ct) 2>/dev/null)'
+`,
+}
+
+// runCompletion prints the completion script for the shell.
+func runCompletion(w io.Writer, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "%s: completion requires one of: bash, zsh, fish\n", cmdName)
+		return exitStatusErr
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "%s: unknown shell %q for completion\n", cmdName, args[0])
+		return exitStatusErr
+	}
+	fmt.Fprintf(w, script, cmdName, completeCommand)
+	return exitStatusOK
+}
+
+// runComplete prints the CronJobs matching the partial "name" or "namespace/name", one per line.
+// Errors are not printed because the output is read by the shell.
+func runComplete(ctx context.Context, w io.Writer, clientset kubernetes.Interface, kubeconfig string, args []string) int {
+	var partial string
+	if len(args) > 0 {
+		partial = args[0]
+	}
+	namespace, _, hasNamespace := strings.Cut(partial, "/")
+	if !hasNamespace {
+		namespace = ""
+	}
+	namespace, err := resolveNamespace(namespace, kubeconfig)
+	if err != nil {
+		return exitStatusErr
+	}
+
+	ga, err := serverCronJobGA(clientset)
+	if err != nil {
+		return exitStatusErr
+	}
+	cronJobs, err := listCronJobs(ctx, clientset, ga, namespace, "")
+	if err != nil {
+		return exitStatusErr
+	}
+	for _, c := range completionCandidates(cronJobs, partial) {
+		fmt.Fprintln(w, c)
+	}
+	return exitStatusOK
+}
+
+// completionCandidates returns the CronJob names which start with partial.
+// When partial has the namespace, the candidates have it too.
+func completionCandidates(cronJobs []batchv1.CronJob, partial string) []string {
+	prefix, name := "", partial
+	if namespace, n, ok := strings.Cut(partial, "/"); ok {
+		prefix, name = namespace+"/", n
+	}
+
+	var candidates []string
+	for _, cj := range cronJobs {
+		if strings.HasPrefix(cj.Name, name) {
+			candidates = append(candidates, prefix+cj.Name)
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCompletionCandidates(t *testing.T) {
+	cronJobs := []batchv1.CronJob{
+		{ObjectMeta: metav1.ObjectMeta{Name: "report-daily"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "report-weekly"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cleanup"}},
+	}
+
+	tests := map[string]struct {
+		partial string
+		expect  []string
+	}{
+		"empty": {
+			partial: "",
+			expect:  []string{"report-daily", "report-weekly", "cleanup"},
+		},
+		"prefix": {
+			partial: "rep",
+			expect:  []string{"report-daily", "report-weekly"},
+		},
+		"with namespace": {
+			partial: "batch/report-d",
+			expect:  []string{"batch/report-daily"},
+		},
+		"namespace only": {
+			partial: "batch/",
+			expect:  []string{"batch/report-daily", "batch/report-weekly", "batch/cleanup"},
+		},
+		"no match": {
+			partial: "migrate",
+			expect:  nil,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got := completionCandidates(cronJobs, tt.partial)
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Errorf("completionCandidates diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var buf bytes.Buffer
+		if status := runCompletion(&buf, []string{shell}); status != exitStatusOK {
+			t.Fatalf("runCompletion(%s) returned %d", shell, status)
+		}
+		if !strings.Contains(buf.String(), "kj __complete") {
+			t.Errorf("%s script should call kj __complete, got\n%s", shell, buf.String())
+		}
+	}
+
+	if status := runCompletion(&bytes.Buffer{}, []string{"powershell"}); status != exitStatusErr {
+		t.Errorf("runCompletion should fail for unknown shell, got %d", status)
+	}
+}
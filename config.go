@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/goccy/go-yaml"
+	"k8s.io/client-go/util/homedir"
+)
+
+// configPath returns $XDG_CONFIG_HOME/kj/config.yaml, or ~/.config/kj/config.yaml.
+func configPath(getenv func(string) string) string {
+	if dir := getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, cmdName, "config.yaml")
+	}
+	if home := homedir.HomeDir(); home != "" {
+		return filepath.Join(home, ".config", cmdName, "config.yaml")
+	}
+	return ""
+}
+
+// loadConfigFile sets the flag values written in the config file. The keys are the flag names.
+// It must be called before parsing the command line so that the flags override the file.
+// The values of repeatable flags in the file are followed by the ones in the command line.
+func loadConfigFile(flags *flag.FlagSet, path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var config map[string]any
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for name, value := range config {
+		if flags.Lookup(name) == nil {
+			return fmt.Errorf("%s: unknown option %q", path, name)
+		}
+
+		values, ok := value.([]any)
+		if !ok {
+			values = []any{value}
+		}
+		for _, v := range values {
+			if err := flags.Set(name, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("%s: invalid value for %q: %w", path, name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := `field-manager: from-file
+smart-apply: true
+env:
+  - FOO=file
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	tests := map[string]struct {
+		args         []string
+		fieldManager string
+		smartApply   bool
+		env          keyValueFlag
+	}{
+		"config file overrides defaults": {
+			args:         nil,
+			fieldManager: "from-file",
+			smartApply:   true,
+			env:          keyValueFlag{"FOO=file"},
+		},
+		"command line overrides config file": {
+			args:         []string{"-field-manager=from-cli", "-smart-apply=false", "-env=BAR=cli"},
+			fieldManager: "from-cli",
+			smartApply:   false,
+			env:          keyValueFlag{"FOO=file", "BAR=cli"},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			flags := flag.NewFlagSet(cmdName, flag.ContinueOnError)
+			fieldManager := flags.String("field-manager", cmdName, "")
+			smartApply := flags.Bool("smart-apply", false, "")
+			var env keyValueFlag
+			flags.Var(&env, "env", "")
+
+			if err := loadConfigFile(flags, path); err != nil {
+				t.Fatalf("loadConfigFile got error: %v", err)
+			}
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+
+			if *fieldManager != tt.fieldManager {
+				t.Errorf(`field-manager expected "%s", got "%s"`, tt.fieldManager, *fieldManager)
+			}
+			if *smartApply != tt.smartApply {
+				t.Errorf("smart-apply expected %t, got %t", tt.smartApply, *smartApply)
+			}
+			if diff := cmp.Diff(tt.env, env); diff != "" {
+				t.Errorf("env diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFile_UnknownOption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("unknown: true\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	flags := flag.NewFlagSet(cmdName, flag.ContinueOnError)
+	if err := loadConfigFile(flags, path); err == nil {
+		t.Errorf("loadConfigFile should return error for unknown option")
+	}
+}
+
+func TestLoadConfigFile_NotExist(t *testing.T) {
+	flags := flag.NewFlagSet(cmdName, flag.ContinueOnError)
+	if err := loadConfigFile(flags, filepath.Join(t.TempDir(), "config.yaml")); err != nil {
+		t.Errorf("loadConfigFile should ignore the missing file, got %v", err)
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	got := configPath(func(key string) string {
+		if key == "XDG_CONFIG_HOME" {
+			return "/xdg"
+		}
+		return ""
+	})
+	if got != "/xdg/kj/config.yaml" {
+		t.Errorf(`configPath expected "/xdg/kj/config.yaml", got "%s"`, got)
+	}
+}
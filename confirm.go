@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errInputStopped is returned by waitInput when the confirmation doesn't need the input anymore.
+var errInputStopped = errors.New("the input is not needed anymore")
+
+type confirmOptions struct {
+	prompt string
+	// defaultYes is the answer when the user just presses enter.
+	defaultYes bool
+	// timeout cancels the confirmation when nobody answers. Zero means waiting forever.
+	timeout time.Duration
+}
+
+// confirmByUser asks the prompt until the user answers y(es) or n(o) in any case.
+// SIGINT, the end of input and the timeout are treated as no.
+// readLine is left running when the confirmation returns, so it should stop by itself, e.g. by waitInput.
+func confirmByUser(readLine func() (string, error), w io.Writer, o confirmOptions) (bool, error) {
+	choices := "[y/N]"
+	if o.defaultYes {
+		choices = "[Y/n]"
+	}
+	fmt.Fprintf(w, "%s %s\n", o.prompt, choices)
+
+	var timeout <-chan time.Time
+	if o.timeout > 0 {
+		timeout = time.After(o.timeout)
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT)
+	defer signal.Stop(sigs)
+
+	answerCh := make(chan string)
+	errCh := make(chan error)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		for {
+			answer, err := readLine()
+			if err != nil {
+				select {
+				case errCh <- err:
+				case <-done:
+				}
+				return
+			}
+			select {
+			case answerCh <- answer:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-sigs:
+			return false, nil
+		case <-timeout:
+			fmt.Fprintf(w, "No answer in %s\n", o.timeout)
+			return false, nil
+		case answer := <-answerCh:
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			switch answer {
+			case "y", "yes":
+				return true, nil
+			case "n", "no":
+				return false, nil
+			case "":
+				return o.defaultYes, nil
+			default:
+				fmt.Fprint(w, "Please answer y or n: \n")
+			}
+		case err := <-errCh:
+			if errors.Is(err, io.EOF) {
+				// Nobody can answer anymore.
+				return false, nil
+			}
+			return false, err
+		}
+	}
+}
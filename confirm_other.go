@@ -0,0 +1,16 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// waitInput returns immediately because the terminal can't be polled. The pending read
+// is left behind when the confirmation returns.
+func waitInput(f *os.File, stop <-chan struct{}) error {
+	select {
+	case <-stop:
+		return errInputStopped
+	default:
+		return nil
+	}
+}
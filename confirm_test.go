@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfirmByUser(t *testing.T) {
+	tests := map[string]struct {
+		input      string
+		defaultYes bool
+		expect     bool
+		output     string
+	}{
+		"y": {
+			input:  "y\n",
+			expect: true,
+			output: "create? [y/N]\n",
+		},
+		"n with default yes": {
+			input:      "n\n",
+			defaultYes: true,
+			expect:     false,
+			output:     "create? [Y/n]\n",
+		},
+		"empty answer is default no": {
+			input:  "\n",
+			expect: false,
+			output: "create? [y/N]\n",
+		},
+		"empty answer is default yes": {
+			input:      "\n",
+			defaultYes: true,
+			expect:     true,
+			output:     "create? [Y/n]\n",
+		},
+		"yes in full": {
+			input:  "yes\n",
+			expect: true,
+			output: "create? [y/N]\n",
+		},
+		"YES in upper case": {
+			input:  "YES\n",
+			expect: true,
+			output: "create? [y/N]\n",
+		},
+		"no in full with default yes": {
+			input:      "No\n",
+			defaultYes: true,
+			expect:     false,
+			output:     "create? [Y/n]\n",
+		},
+		"answer with spaces": {
+			input:  "  yes  \n",
+			expect: true,
+			output: "create? [y/N]\n",
+		},
+		"partial word asks again": {
+			input:  "ye\nnope\nno\n",
+			expect: false,
+			output: "create? [y/N]\nPlease answer y or n: \nPlease answer y or n: \n",
+		},
+		"invalid answer asks again": {
+			input:  "maybe\nY\n",
+			expect: true,
+			output: "create? [y/N]\nPlease answer y or n: \n",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := confirmByUser(lineReader(strings.NewReader(tt.input)), &out, confirmOptions{prompt: "create?", defaultYes: tt.defaultYes})
+			if err != nil {
+				t.Fatalf("confirmByUser got error: %v", err)
+			}
+			if got != tt.expect {
+				t.Errorf("confirmByUser expected %t, got %t", tt.expect, got)
+			}
+			if out.String() != tt.output {
+				t.Errorf("confirmByUser output expected %q, got %q", tt.output, out.String())
+			}
+		})
+	}
+}
+
+func TestConfirmByUser_EOF(t *testing.T) {
+	done := make(chan struct{})
+	var got bool
+	var err error
+	go func() {
+		defer close(done)
+		got, err = confirmByUser(lineReader(strings.NewReader("")), io.Discard, confirmOptions{prompt: "create?", defaultYes: true})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("confirmByUser should return promptly when the input is closed")
+	}
+	if err != nil {
+		t.Fatalf("confirmByUser got error: %v", err)
+	}
+	if got {
+		t.Errorf("confirmByUser should cancel when the input is closed")
+	}
+}
+
+func TestConfirmByUser_Timeout(t *testing.T) {
+	// readLine never returns
+	block := make(chan struct{})
+	defer close(block)
+	readLine := func() (string, error) {
+		<-block
+		return "", io.EOF
+	}
+
+	var out bytes.Buffer
+	got, err := confirmByUser(readLine, &out, confirmOptions{prompt: "create?", defaultYes: true, timeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("confirmByUser got error: %v", err)
+	}
+	if got {
+		t.Errorf("confirmByUser should cancel on timeout")
+	}
+	if !strings.Contains(out.String(), "No answer in 10ms") {
+		t.Errorf("confirmByUser should print the timeout message, got %q", out.String())
+	}
+}
+
+// lineReader returns the function which reads r line by line.
+func lineReader(r io.Reader) func() (string, error) {
+	br := bufio.NewReader(r)
+	return func() (string, error) {
+		line, err := br.ReadString('\n')
+		if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+}
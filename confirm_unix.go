@@ -0,0 +1,39 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// waitInput waits until f has the input to read, or returns errInputStopped when stop is closed.
+// f is polled instead of being read because a blocking read of the terminal can't be interrupted.
+func waitInput(f *os.File, stop <-chan struct{}) error {
+	fds := []unix.PollFd{{Fd: int32(f.Fd()), Events: unix.POLLIN}}
+	for {
+		select {
+		case <-stop:
+			return errInputStopped
+		default:
+		}
+		n, err := unix.Poll(fds, 100)
+		if err != nil {
+			if errors.Is(err, unix.EINTR) {
+				continue
+			}
+			return err
+		}
+		if n > 0 {
+			// The input which came after stop belongs to the next reader.
+			select {
+			case <-stop:
+				return errInputStopped
+			default:
+				return nil
+			}
+		}
+	}
+}
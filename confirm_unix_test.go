@@ -0,0 +1,114 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// newTTYLikePipe returns the pipe whose reader is in the blocking mode like the terminal opened by go-tty,
+// and the line reader which reads it byte by byte like ttyutil.ReadLine after waitInput.
+// The pipe is closed after the line reader is stopped by closing stop.
+func newTTYLikePipe(t *testing.T, stop <-chan struct{}) (*os.File, *os.File, func() (string, error)) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopped := make(chan struct{})
+	t.Cleanup(func() {
+		<-stopped
+		r.Close()
+		w.Close()
+	})
+	// Fd puts the file into the blocking mode, where SetReadDeadline can't interrupt a read.
+	r.Fd()
+
+	readLine := func() (string, error) {
+		if err := waitInput(r, stop); err != nil {
+			if errors.Is(err, errInputStopped) {
+				close(stopped)
+			}
+			return "", err
+		}
+		var line []byte
+		b := make([]byte, 1)
+		for {
+			if _, err := r.Read(b); err != nil {
+				return "", err
+			}
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, b[0])
+		}
+	}
+	return r, w, readLine
+}
+
+// confirmWithin fails the test when confirmByUser doesn't return in time.
+func confirmWithin(t *testing.T, readLine func() (string, error), o confirmOptions) bool {
+	t.Helper()
+	type result struct {
+		ok  bool
+		err error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		ok, err := confirmByUser(readLine, io.Discard, o)
+		resultCh <- result{ok, err}
+	}()
+	select {
+	case r := <-resultCh:
+		if r.err != nil {
+			t.Fatalf("confirmByUser got error: %v", r.err)
+		}
+		return r.ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("confirmByUser didn't return")
+	}
+	return false
+}
+
+func TestConfirmByUser_AnswerOnBlockingFile(t *testing.T) {
+	stop := make(chan struct{})
+	_, w, readLine := newTTYLikePipe(t, stop)
+	if _, err := w.WriteString("y\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := confirmWithin(t, readLine, confirmOptions{prompt: "create?"})
+	close(stop)
+	if !got {
+		t.Error("confirmByUser expected true for y")
+	}
+}
+
+func TestConfirmByUser_TimeoutReleasesInput(t *testing.T) {
+	stop := make(chan struct{})
+	r, w, readLine := newTTYLikePipe(t, stop)
+
+	got := confirmWithin(t, readLine, confirmOptions{prompt: "create?", timeout: 10 * time.Millisecond})
+	close(stop)
+	if got {
+		t.Errorf("confirmByUser should cancel on timeout")
+	}
+
+	// The line typed after the timeout belongs to the next reader.
+	if _, err := w.WriteString("y\n"); err != nil {
+		t.Fatal(err)
+	}
+	// Give the stopped reader the time to see the input.
+	time.Sleep(200 * time.Millisecond)
+	line, err := lineReader(r)()
+	if err != nil {
+		t.Fatalf("read after the timeout got error: %v", err)
+	}
+	if line != "y" {
+		t.Errorf(`the line after the timeout expected "y", got %q`, line)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/kubernetes"
+)
+
+// runDescribe prints a summary of the Job which would be created from the CronJob without creating it.
+// The Job is built by newJob, so the overrides given by the flags are reflected.
+func runDescribe(ctx context.Context, clientset kubernetes.Interface, kubeconfig string, opts jobOptions, args []string) int {
+	namespace, name, ok := getNamespaceAndName(args)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "%s: argments are invalid\n", cmdName)
+		return exitStatusErr
+	}
+
+	namespace, err := resolveNamespace(namespace, kubeconfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
+		return exitStatusErr
+	}
+
+	ga, err := serverCronJobGA(clientset)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
+		return exitStatusErr
+	}
+
+	cj, err := getCronJob(ctx, clientset, ga, namespace, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
+		return exitStatusErr
+	}
+
+	job, err := newJob(cj, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
+		return exitStatusErr
+	}
+
+	// The context is informational only, so a broken kubeconfig is not an error here.
+	kc, _ := loadKubeconfig(kubeconfig)
+	if err := describeJob(os.Stdout, cj, job, kc.CurrentContext); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
+		return exitStatusErr
+	}
+	return exitStatusOK
+}
+
+// describeJob prints the CronJob and the Job built from it in the style of kubectl describe.
+func describeJob(w io.Writer, cj *batchv1.CronJob, job *batchv1.Job, kubeContext string) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "CronJob:\t%s/%s\n", cj.Namespace, cj.Name)
+	fmt.Fprintf(tw, "Context:\t%s\n", valueOrNone(kubeContext))
+	fmt.Fprintf(tw, "Schedule:\t%s\n", cj.Spec.Schedule)
+	fmt.Fprintf(tw, "TimeZone:\t%s\n", valueOrNone(deref(cj.Spec.TimeZone)))
+	fmt.Fprintf(tw, "Suspend:\t%t\n", deref(cj.Spec.Suspend))
+	jobName := job.Name
+	if jobName == "" {
+		jobName = job.GenerateName + "<generated>"
+	}
+	fmt.Fprintf(tw, "Job:\t%s/%s\n", job.Namespace, jobName)
+	fmt.Fprintf(tw, "Owner:\t%s\n", formatOwners(job.OwnerReferences))
+	fmt.Fprintf(tw, "Containers:\n")
+	for _, c := range job.Spec.Template.Spec.Containers {
+		fmt.Fprintf(tw, "  %s:\n", c.Name)
+		fmt.Fprintf(tw, "    Image:\t%s\n", c.Image)
+		fmt.Fprintf(tw, "    Command:\t%s\n", valueOrNone(strings.Join(c.Command, " ")))
+		fmt.Fprintf(tw, "    Args:\t%s\n", valueOrNone(strings.Join(c.Args, " ")))
+		fmt.Fprintf(tw, "    Env:\t%d\n", len(c.Env))
+		fmt.Fprintf(tw, "    EnvFrom:\t%d\n", len(c.EnvFrom))
+		fmt.Fprintf(tw, "    Requests:\t%s\n", valueOrNone(formatResourceList(c.Resources.Requests)))
+	}
+	return tw.Flush()
+}
+
+// formatCronJobInfo returns the one line summary of the CronJob printed before editing.
+// The last schedule time is rendered in the location of now.
+func formatCronJobInfo(cj *batchv1.CronJob, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cronjob.batch/%s: schedule=%q", cj.Name, cj.Spec.Schedule)
+	if tz := deref(cj.Spec.TimeZone); tz != "" {
+		fmt.Fprintf(&b, " timeZone=%s", tz)
+	}
+	lastSchedule := "<none>"
+	if t := cj.Status.LastScheduleTime; t != nil {
+		lastSchedule = fmt.Sprintf("%s (%s ago)", t.In(now.Location()).Format(time.RFC3339), duration.HumanDuration(now.Sub(t.Time)))
+	}
+	fmt.Fprintf(&b, " lastSchedule=%s suspend=%t", lastSchedule, deref(cj.Spec.Suspend))
+	return b.String()
+}
+
+func formatResourceList(l corev1.ResourceList) string {
+	s := make([]string, 0, len(l))
+	for k, v := range l {
+		s = append(s, fmt.Sprintf("%s=%s", k, v.String()))
+	}
+	sort.Strings(s)
+	return strings.Join(s, ", ")
+}
+
+func formatOwners(refs []metav1.OwnerReference) string {
+	owners := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		owners = append(owners, ref.Kind+"/"+ref.Name)
+	}
+	return valueOrNone(strings.Join(owners, ", "))
+}
+
+func valueOrNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+func deref[T any](t *T) T {
+	if t == nil {
+		var zero T
+		return zero
+	}
+	return *t
+}
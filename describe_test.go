@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDescribeCronJob(t *testing.T) {
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec: batchv1.CronJobSpec{
+			Schedule: "*/5 * * * *",
+			TimeZone: toPtr("Asia/Tokyo"),
+			Suspend:  toPtr(true),
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:    "app",
+									Image:   "app:v1",
+									Command: []string{"echo", "hello"},
+									Resources: corev1.ResourceRequirements{
+										Requests: corev1.ResourceList{
+											corev1.ResourceMemory: resource.MustParse("128Mi"),
+											corev1.ResourceCPU:    resource.MustParse("100m"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	job, err := newJob(cj, jobOptions{env: keyValueFlag{"DEBUG=true"}, envFrom: stringsFlag{"secret:credentials"}})
+	if err != nil {
+		t.Fatalf("newJob got error: %v", err)
+	}
+	job.Name = "test-abcdef"
+
+	var buf bytes.Buffer
+	if err := describeJob(&buf, cj, job, "ctx"); err != nil {
+		t.Fatalf("describeJob got error: %v", err)
+	}
+
+	expect := `CronJob:   default/test
+Context:   ctx
+Schedule:  */5 * * * *
+TimeZone:  Asia/Tokyo
+Suspend:   true
+Job:       default/test-abcdef
+Owner:     CronJob/test
+Containers:
+  app:
+    Image:     app:v1
+    Command:   echo hello
+    Args:      <none>
+    Env:       1
+    EnvFrom:   1
+    Requests:  cpu=100m, memory=128Mi
+`
+	if diff := cmp.Diff(expect, buf.String()); diff != "" {
+		t.Errorf("describeJob result diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestFormatCronJobInfo(t *testing.T) {
+	now := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		cj       *batchv1.CronJob
+		location *time.Location
+		expect   string
+	}{
+		"scheduled with time zone": {
+			cj: &batchv1.CronJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "report"},
+				Spec:       batchv1.CronJobSpec{Schedule: "0 3 * * *", TimeZone: toPtr("Asia/Tokyo")},
+				Status:     batchv1.CronJobStatus{LastScheduleTime: &metav1.Time{Time: now.Add(-3 * time.Hour)}},
+			},
+			expect: `cronjob.batch/report: schedule="0 3 * * *" timeZone=Asia/Tokyo lastSchedule=2024-04-01T09:00:00Z (3h ago) suspend=false`,
+		},
+		"in -timezone": {
+			cj: &batchv1.CronJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "report"},
+				Spec:       batchv1.CronJobSpec{Schedule: "0 3 * * *"},
+				Status:     batchv1.CronJobStatus{LastScheduleTime: &metav1.Time{Time: now.Add(-3 * time.Hour)}},
+			},
+			location: tokyo,
+			expect:   `cronjob.batch/report: schedule="0 3 * * *" lastSchedule=2024-04-01T18:00:00+09:00 (3h ago) suspend=false`,
+		},
+		"never scheduled and suspended": {
+			cj: &batchv1.CronJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "report"},
+				Spec:       batchv1.CronJobSpec{Schedule: "@daily", Suspend: toPtr(true)},
+			},
+			expect: `cronjob.batch/report: schedule="@daily" lastSchedule=<none> suspend=true`,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			now := now
+			if tt.location != nil {
+				now = now.In(tt.location)
+			}
+			got := formatCronJobInfo(tt.cj, now)
+			if got != tt.expect {
+				t.Errorf("formatCronJobInfo expected\n%s\ngot\n%s", tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+// jobChange is a field changed in the editor. Old or New is nil when the field is added or removed.
+type jobChange struct {
+	Path string `json:"path"`
+	Old  any    `json:"old"`
+	New  any    `json:"new"`
+}
+
+// diffJobs returns the changes from the generated Job to the edited Job, sorted by the path.
+func diffJobs(before, after *batchv1.Job) ([]jobChange, error) {
+	b, err := toObject(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := toObject(after)
+	if err != nil {
+		return nil, err
+	}
+	removeEmptyServerFields(b)
+	removeEmptyServerFields(a)
+
+	changes := diffValues("", b, a)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// diffValues walks the decoded JSON objects and returns the changed leaves.
+// The path is like "spec.template.spec.containers[0].image".
+func diffValues(path string, before, after any) []jobChange {
+	switch b := before.(type) {
+	case map[string]any:
+		a, ok := after.(map[string]any)
+		if !ok {
+			break
+		}
+		var changes []jobChange
+		for key, bv := range b {
+			changes = append(changes, diffValues(joinPath(path, key), bv, a[key])...)
+		}
+		for key, av := range a {
+			if _, ok := b[key]; !ok {
+				changes = append(changes, diffValues(joinPath(path, key), nil, av)...)
+			}
+		}
+		return changes
+	case []any:
+		a, ok := after.([]any)
+		if !ok {
+			break
+		}
+		var changes []jobChange
+		for i := 0; i < max(len(a), len(b)); i++ {
+			var bv, av any
+			if i < len(b) {
+				bv = b[i]
+			}
+			if i < len(a) {
+				av = a[i]
+			}
+			changes = append(changes, diffValues(fmt.Sprintf("%s[%d]", path, i), bv, av)...)
+		}
+		return changes
+	}
+
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+	return []jobChange{{Path: path, Old: before, New: after}}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// printDiff prints the changes in "text" or "json" format.
+func printDiff(w io.Writer, format string, changes []jobChange) error {
+	switch format {
+	case "json":
+		if changes == nil {
+			changes = []jobChange{}
+		}
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(changes)
+	case "text":
+		for _, c := range changes {
+			fmt.Fprintf(w, "%s: %s -> %s\n", c.Path, diffValueString(c.Old), diffValueString(c.New))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown diff format %q", format)
+}
+
+func diffValueString(v any) string {
+	if v == nil {
+		return "<none>"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiffJobs(t *testing.T) {
+	before := &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-abc123"},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: toPtr(int32(6)),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Image: "app:v1", Args: []string{"run"}},
+					},
+				},
+			},
+		},
+	}
+	after := before.DeepCopy()
+	after.Spec.BackoffLimit = toPtr(int32(0))
+	after.Spec.Template.Spec.Containers[0].Image = "app:v2"
+	after.Spec.Template.Spec.Containers[0].Args = append(after.Spec.Template.Spec.Containers[0].Args, "--dry-run")
+	after.Labels = map[string]string{"debug": "true"}
+
+	changes, err := diffJobs(before, after)
+	if err != nil {
+		t.Fatalf("diffJobs got error: %v", err)
+	}
+
+	paths := make([]string, 0, len(changes))
+	for _, c := range changes {
+		paths = append(paths, c.Path)
+	}
+	expect := []string{
+		"metadata.labels",
+		"spec.backoffLimit",
+		"spec.template.spec.containers[0].args[1]",
+		"spec.template.spec.containers[0].image",
+	}
+	if diff := cmp.Diff(expect, paths); diff != "" {
+		t.Errorf("changed paths diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestPrintDiff(t *testing.T) {
+	changes := []jobChange{
+		{Path: "spec.backoffLimit", Old: json.Number("6"), New: json.Number("0")},
+		{Path: "metadata.labels", New: map[string]any{"debug": "true"}},
+	}
+
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := printDiff(&buf, "text", changes); err != nil {
+			t.Fatalf("printDiff got error: %v", err)
+		}
+		expect := `spec.backoffLimit: 6 -> 0
+metadata.labels: <none> -> {"debug":"true"}
+`
+		if diff := cmp.Diff(expect, buf.String()); diff != "" {
+			t.Errorf("text diff (-expect, +got)\n%s", diff)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := printDiff(&buf, "json", changes); err != nil {
+			t.Fatalf("printDiff got error: %v", err)
+		}
+		var got []map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+		}
+		if len(got) != 2 || got[0]["path"] != "spec.backoffLimit" || got[1]["old"] != nil {
+			t.Errorf("unexpected json diff: %s", buf.String())
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if err := printDiff(&bytes.Buffer{}, "yaml", changes); err == nil {
+			t.Error("printDiff should return error for unknown format")
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// watchJobEvents prints the events of the Job and its pods until the Job finishes or ctx is done.
+// The watches are opened again when the server closes them, e.g. by the client timeout of -timeout.
+func watchJobEvents(ctx context.Context, w io.Writer, clientset kubernetes.Interface, namespace, jobName string) error {
+	// The field selector can't match the pod names by prefix, so the events are filtered by isJobEvent.
+	// The resource version of the last event resumes the watch without printing the events again.
+	var eventsVersion string
+	watchEvents := func() (watch.Interface, error) {
+		events, err := clientset.CoreV1().Events(namespace).Watch(ctx, metav1.ListOptions{ResourceVersion: eventsVersion})
+		if err != nil {
+			return nil, fmt.Errorf("failed to watch events: %w", err)
+		}
+		return events, nil
+	}
+	// The Job is watched from its current state, so a Job which finished while reconnecting is noticed.
+	watchJob := func() (watch.Interface, error) {
+		jobs, err := clientset.BatchV1().Jobs(namespace).Watch(ctx, metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("metadata.name", jobName).String(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to watch job: %w", err)
+		}
+		return jobs, nil
+	}
+
+	events, err := watchEvents()
+	if err != nil {
+		return err
+	}
+	defer func() { events.Stop() }()
+	jobs, err := watchJob()
+	if err != nil {
+		return err
+	}
+	defer func() { jobs.Stop() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-events.ResultChan():
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				logf(4, "the event watch was closed, watching again")
+				reopened, err := watchEvents()
+				if err != nil {
+					return err
+				}
+				events = reopened
+				continue
+			}
+			if ev.Type == watch.Error {
+				// The resource version is too old. Resume from the current events.
+				eventsVersion = ""
+				continue
+			}
+			if e, ok := ev.Object.(*corev1.Event); ok {
+				eventsVersion = e.ResourceVersion
+				if isJobEvent(e, jobName) {
+					fmt.Fprintln(w, formatEvent(e))
+				}
+			}
+		case ev, ok := <-jobs.ResultChan():
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				logf(4, "the job watch was closed, watching again")
+				reopened, err := watchJob()
+				if err != nil {
+					return err
+				}
+				jobs = reopened
+				continue
+			}
+			if job, ok := ev.Object.(*batchv1.Job); ok && jobFinished(job) {
+				return nil
+			}
+		}
+	}
+}
+
+// isJobEvent reports whether e is about the Job or one of its pods, which are named "<job>-<suffix>".
+func isJobEvent(e *corev1.Event, jobName string) bool {
+	switch e.InvolvedObject.Kind {
+	case "Job":
+		return e.InvolvedObject.Name == jobName
+	case "Pod":
+		return strings.HasPrefix(e.InvolvedObject.Name, jobName+"-")
+	}
+	return false
+}
+
+func jobFinished(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEvent(e *corev1.Event) string {
+	return fmt.Sprintf("%s %s/%s %s: %s", e.Type, strings.ToLower(e.InvolvedObject.Kind), e.InvolvedObject.Name, e.Reason, strings.TrimSpace(e.Message))
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestFormatEvent(t *testing.T) {
+	e := &corev1.Event{
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "report-abc123-x7k2p"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "FailedScheduling",
+		Message:        "0/3 nodes are available: 3 Insufficient memory.\n",
+	}
+
+	expect := "Warning pod/report-abc123-x7k2p FailedScheduling: 0/3 nodes are available: 3 Insufficient memory."
+	if got := formatEvent(e); got != expect {
+		t.Errorf(`formatEvent expected "%s", got "%s"`, expect, got)
+	}
+}
+
+func TestIsJobEvent(t *testing.T) {
+	tests := map[string]struct {
+		object corev1.ObjectReference
+		expect bool
+	}{
+		"job": {
+			object: corev1.ObjectReference{Kind: "Job", Name: "report-abc123"},
+			expect: true,
+		},
+		"pod of the job": {
+			object: corev1.ObjectReference{Kind: "Pod", Name: "report-abc123-x7k2p"},
+			expect: true,
+		},
+		"other job": {
+			object: corev1.ObjectReference{Kind: "Job", Name: "report-def456"},
+		},
+		"pod of other job": {
+			object: corev1.ObjectReference{Kind: "Pod", Name: "report-def456-x7k2p"},
+		},
+		"cronjob": {
+			object: corev1.ObjectReference{Kind: "CronJob", Name: "report-abc123"},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got := isJobEvent(&corev1.Event{InvolvedObject: tt.object}, "report-abc123")
+			if got != tt.expect {
+				t.Errorf("isJobEvent expected %t, got %t", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestJobFinished(t *testing.T) {
+	tests := map[string]struct {
+		conditions []batchv1.JobCondition
+		expect     bool
+	}{
+		"running": {},
+		"complete": {
+			conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+			expect:     true,
+		},
+		"failed": {
+			conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+			expect:     true,
+		},
+		"suspended": {
+			conditions: []batchv1.JobCondition{{Type: batchv1.JobSuspended, Status: corev1.ConditionTrue}},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			job := &batchv1.Job{Status: batchv1.JobStatus{Conditions: tt.conditions}}
+			if got := jobFinished(job); got != tt.expect {
+				t.Errorf("jobFinished expected %t, got %t", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestWatchJobEvents_Reconnect(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	eventWatches := []*watch.FakeWatcher{watch.NewFake(), watch.NewFake()}
+	var eventVersions []string
+	clientset.PrependWatchReactor("events", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		eventVersions = append(eventVersions, action.(k8stesting.WatchActionImpl).WatchRestrictions.ResourceVersion)
+		w := eventWatches[0]
+		eventWatches = eventWatches[1:]
+		return true, w, nil
+	})
+	jobWatch := watch.NewFake()
+	clientset.PrependWatchReactor("jobs", func(k8stesting.Action) (bool, watch.Interface, error) {
+		return true, jobWatch, nil
+	})
+
+	newEvent := func(version, reason string) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{ResourceVersion: version},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "test-abcdef-x7k2p"},
+			Type:           corev1.EventTypeNormal,
+			Reason:         reason,
+			Message:        reason,
+		}
+	}
+	first, second := eventWatches[0], eventWatches[1]
+	go func() {
+		first.Add(newEvent("1", "Scheduled"))
+		// The watch is closed by the client timeout before the Job finishes.
+		first.Stop()
+		second.Add(newEvent("2", "Started"))
+		jobWatch.Modify(&batchv1.Job{Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+		}})
+	}()
+
+	var buf bytes.Buffer
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := watchJobEvents(ctx, &buf, clientset, "default", "test-abcdef"); err != nil {
+		t.Fatalf("watchJobEvents got error: %v", err)
+	}
+
+	expect := "Normal pod/test-abcdef-x7k2p Scheduled: Scheduled\nNormal pod/test-abcdef-x7k2p Started: Started\n"
+	if diff := cmp.Diff(expect, buf.String()); diff != "" {
+		t.Errorf("watchJobEvents output diff (-expect, +got)\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"", "1"}, eventVersions); diff != "" {
+		t.Errorf("the watch should be resumed from the last event, diff (-expect, +got)\n%s", diff)
+	}
+}
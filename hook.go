@@ -0,0 +1,26 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+// runHook runs command split in the same way as the editor.
+// The Job is passed as KJ_JOB_NAME and KJ_NAMESPACE. The name is empty before the Job
+// is created with -generate-name.
+func runHook(command string, job *batchv1.Job) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	// stdout is kept for the kubectl output.
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "KJ_JOB_NAME="+job.Name, "KJ_NAMESPACE="+job.Namespace)
+	return cmd.Run()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// writeHookScript writes the script which saves the hook env vars to out.
+func writeHookScript(t *testing.T, dir, out string) string {
+	t.Helper()
+	script := filepath.Join(dir, "hook.sh")
+	body := "#!/bin/sh\necho \"$KJ_NAMESPACE/$KJ_JOB_NAME\" >> " + out + "\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return script
+}
+
+func TestRunHook_Env(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	script := writeHookScript(t, dir, out)
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-abc123"}}
+	if err := runHook(script, job); err != nil {
+		t.Fatalf("runHook got error: %v", err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "default/test-abc123\n" {
+		t.Errorf(`hook env expected "default/test-abc123", got %q`, got)
+	}
+}
+
+func TestJobCreator_ApplyWithHooks(t *testing.T) {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"}}
+
+	t.Run("failing pre-apply aborts", func(t *testing.T) {
+		c := &jobCreator{preApply: "false"}
+		err := c.applyWithHooks(job, func() error {
+			t.Fatal("apply should not be called when the pre-apply hook fails")
+			return nil
+		})
+		if err == nil {
+			t.Error("applyWithHooks should return error when the pre-apply hook fails")
+		}
+	})
+
+	t.Run("failing post-apply is not an error", func(t *testing.T) {
+		c := &jobCreator{postApply: "false"}
+		var applied bool
+		err := c.applyWithHooks(job, func() error {
+			applied = true
+			return nil
+		})
+		if err != nil {
+			t.Errorf("applyWithHooks got error: %v", err)
+		}
+		if !applied {
+			t.Error("apply should be called")
+		}
+	})
+
+	t.Run("hooks run around apply", func(t *testing.T) {
+		dir := t.TempDir()
+		out := filepath.Join(dir, "out")
+		script := writeHookScript(t, dir, out)
+
+		c := &jobCreator{preApply: script, postApply: script}
+		err := c.applyWithHooks(job, func() error {
+			if _, err := os.Stat(out); err != nil {
+				t.Errorf("pre-apply hook should run before apply: %v", err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("applyWithHooks got error: %v", err)
+		}
+		got, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "default/test\ndefault/test\n" {
+			t.Errorf("hooks expected to run twice, got %q", got)
+		}
+	})
+}
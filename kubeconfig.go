@@ -1,7 +1,13 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/goccy/go-yaml"
 )
@@ -20,7 +26,29 @@ type KubeContext struct {
 	Namespace string `yaml:"namespace"`
 }
 
+// loadKubeconfig loads path, which can be a list of files like KUBECONFIG.
+// The files are merged in the same way as kubectl: the first file to set a context or current-context wins,
+// and files that don't exist are ignored.
 func loadKubeconfig(path string) (k Kubeconfig, err error) {
+	var loaded bool
+	for _, p := range filepath.SplitList(path) {
+		c, err := loadKubeconfigFile(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return k, err
+		}
+		loaded = true
+		k.merge(c)
+	}
+	if !loaded {
+		return k, fmt.Errorf("kubeconfig is not found in %s", path)
+	}
+	return k, nil
+}
+
+func loadKubeconfigFile(path string) (k Kubeconfig, err error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return k, err
@@ -32,6 +60,67 @@ func loadKubeconfig(path string) (k Kubeconfig, err error) {
 	return k, err
 }
 
+func (k *Kubeconfig) merge(other Kubeconfig) {
+	if k.CurrentContext == "" {
+		k.CurrentContext = other.CurrentContext
+	}
+	for _, c := range other.Contexts {
+		if !slices.ContainsFunc(k.Contexts, func(kc KubeContexts) bool { return kc.Name == c.Name }) {
+			k.Contexts = append(k.Contexts, c)
+		}
+	}
+}
+
+// serviceAccountNamespaceFile is mounted into pods which use a service account.
+var serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// resolveNamespace returns namespace when it is specified, otherwise the namespace of the current context.
+// When the context has no namespace, the namespace of the service account is used if kj runs in a pod.
+func resolveNamespace(namespace, kubeconfigPath string) (string, error) {
+	if namespace != "" {
+		logf(2, "using namespace %q from the arguments", namespace)
+		return namespace, nil
+	}
+
+	k, err := loadKubeconfig(kubeconfigPath)
+	if err != nil {
+		if ns, ok := inClusterNamespace(serviceAccountNamespaceFile); ok {
+			logf(2, "using namespace %q from %s", ns, serviceAccountNamespaceFile)
+			return ns, nil
+		}
+		return "", err
+	}
+
+	if kc, ok := k.currentContext(); ok && kc.Namespace != "" {
+		logf(2, "using namespace %q from context %q", kc.Namespace, k.CurrentContext)
+		return kc.Namespace, nil
+	}
+	if ns, ok := inClusterNamespace(serviceAccountNamespaceFile); ok {
+		logf(2, "using namespace %q from %s", ns, serviceAccountNamespaceFile)
+		return ns, nil
+	}
+	logf(2, "using namespace %q", k.CurrentNamespace())
+	return k.CurrentNamespace(), nil
+}
+
+func inClusterNamespace(path string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	ns := strings.TrimSpace(string(data))
+	return ns, ns != ""
+}
+
+func kubeconfigExists(path string) bool {
+	for _, p := range filepath.SplitList(path) {
+		if _, err := os.Stat(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
 func (k Kubeconfig) CurrentNamespace() string {
 	kc, ok := k.currentContext()
 	if !ok {
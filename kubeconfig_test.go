@@ -1,7 +1,9 @@
 package main
 
 import (
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -72,3 +74,119 @@ func TestKubeconfig_CurrentNamespace(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveNamespace(t *testing.T) {
+	tests := map[string]struct {
+		namespace string
+		expect    string
+	}{
+		"positional namespace is used": {
+			namespace: "positional",
+			expect:    "positional",
+		},
+		"empty namespace falls back to the current context": {
+			namespace: "",
+			expect:    "nsB",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got, err := resolveNamespace(tt.namespace, kubeconfigFilePath)
+			if err != nil {
+				t.Fatalf("resolveNamespace got error: %v", err)
+			}
+			if got != tt.expect {
+				t.Errorf(`resolveNamespace expected "%s", got "%s"`, tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestLoadKubeConfig_PathList(t *testing.T) {
+	tests := map[string]struct {
+		paths     []string
+		namespace string
+	}{
+		"current-context and its namespace come from different files": {
+			paths:     []string{"testdata/kubeconfig-current", "testdata/kubeconfig-contexts"},
+			namespace: "nsC",
+		},
+		"first file to set a context wins": {
+			paths:     []string{kubeconfigFilePath, "testdata/kubeconfig-contexts"},
+			namespace: "nsB",
+		},
+		"file that doesn't exist is ignored": {
+			paths:     []string{"testdata/not-exist", kubeconfigFilePath},
+			namespace: "nsB",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			k, err := loadKubeconfig(strings.Join(tt.paths, string(filepath.ListSeparator)))
+			if err != nil {
+				t.Fatalf("failed to load kubeconfig: %+v", err)
+			}
+
+			ns := k.CurrentNamespace()
+			if ns != tt.namespace {
+				t.Errorf(`CurrentNamespace expected "%s", got "%s"`, tt.namespace, ns)
+			}
+		})
+	}
+}
+
+func TestLoadKubeConfig_NotFound(t *testing.T) {
+	_, err := loadKubeconfig("testdata/not-exist")
+	if err == nil {
+		t.Errorf("loadKubeconfig should return error when no kubeconfig exists")
+	}
+}
+
+func TestResolveNamespace_InCluster(t *testing.T) {
+	orig := serviceAccountNamespaceFile
+	serviceAccountNamespaceFile = "testdata/serviceaccount/namespace"
+	t.Cleanup(func() { serviceAccountNamespaceFile = orig })
+
+	tests := map[string]struct {
+		kubeconfig string
+		expect     string
+	}{
+		"kubeconfig doesn't exist": {
+			kubeconfig: "testdata/not-exist",
+			expect:     "ns-from-sa",
+		},
+		"context has no namespace": {
+			kubeconfig: "testdata/kubeconfig-current",
+			expect:     "ns-from-sa",
+		},
+		"context namespace wins": {
+			kubeconfig: kubeconfigFilePath,
+			expect:     "nsB",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got, err := resolveNamespace("", tt.kubeconfig)
+			if err != nil {
+				t.Fatalf("resolveNamespace got error: %v", err)
+			}
+			if got != tt.expect {
+				t.Errorf(`resolveNamespace expected "%s", got "%s"`, tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestInClusterNamespace(t *testing.T) {
+	ns, ok := inClusterNamespace("testdata/serviceaccount/namespace")
+	if !ok || ns != "ns-from-sa" {
+		t.Errorf(`inClusterNamespace expected "ns-from-sa", got "%s" (ok=%t)`, ns, ok)
+	}
+
+	if _, ok := inClusterNamespace("testdata/not-exist"); ok {
+		t.Errorf("inClusterNamespace should return false when the file doesn't exist")
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// runList prints the CronJobs in the namespace.
+func runList(ctx context.Context, clientset kubernetes.Interface, kubeconfig string, args []string) int {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	output := flags.String("o", "table", "output format. One of: table, json, yaml")
+	if err := flags.Parse(args); err != nil {
+		return exitStatusErr
+	}
+	args = flags.Args()
+	if len(args) > 1 {
+		fmt.Fprintf(os.Stderr, "%s: argments are invalid\n", cmdName)
+		return exitStatusErr
+	}
+	switch *output {
+	case "table", "json", "yaml":
+	default:
+		fmt.Fprintf(os.Stderr, "%s: unknown output format %q for list\n", cmdName, *output)
+		return exitStatusErr
+	}
+
+	var namespace string
+	if len(args) == 1 {
+		namespace = args[0]
+	}
+	namespace, err := resolveNamespace(namespace, kubeconfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
+		return exitStatusErr
+	}
+
+	ga, err := serverCronJobGA(clientset)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
+		return exitStatusErr
+	}
+
+	cronJobs, err := listCronJobs(ctx, clientset, ga, namespace, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
+		return exitStatusErr
+	}
+
+	switch *output {
+	case "json":
+		err = printCronJobsJSON(os.Stdout, cronJobs)
+	case "yaml":
+		err = printCronJobsYAML(os.Stdout, cronJobs)
+	default:
+		err = printCronJobs(os.Stdout, cronJobs, time.Now())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
+		return exitStatusErr
+	}
+	return exitStatusOK
+}
+
+func printCronJobs(w io.Writer, cronJobs []batchv1.CronJob, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSCHEDULE\tSUSPEND\tLAST SCHEDULE")
+	for _, cj := range cronJobs {
+		lastSchedule := "<none>"
+		if t := cj.Status.LastScheduleTime; t != nil {
+			lastSchedule = duration.HumanDuration(now.Sub(t.Time))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\n", cj.Name, cj.Spec.Schedule, deref(cj.Spec.Suspend), lastSchedule)
+	}
+	return tw.Flush()
+}
+
+// cronJobSummary is an entry of "kj list -o json" and "-o yaml".
+type cronJobSummary struct {
+	Name         string     `json:"name"`
+	Namespace    string     `json:"namespace"`
+	Schedule     string     `json:"schedule"`
+	Suspend      bool       `json:"suspend"`
+	LastSchedule *time.Time `json:"lastSchedule"`
+}
+
+func summarizeCronJobs(cronJobs []batchv1.CronJob) []cronJobSummary {
+	// The empty list is printed as [] rather than null.
+	summaries := make([]cronJobSummary, 0, len(cronJobs))
+	for _, cj := range cronJobs {
+		s := cronJobSummary{
+			Name:      cj.Name,
+			Namespace: cj.Namespace,
+			Schedule:  cj.Spec.Schedule,
+			Suspend:   deref(cj.Spec.Suspend),
+		}
+		if t := cj.Status.LastScheduleTime; t != nil {
+			s.LastSchedule = toPtr(t.UTC())
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries
+}
+
+func printCronJobsJSON(w io.Writer, cronJobs []batchv1.CronJob) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(summarizeCronJobs(cronJobs))
+}
+
+func printCronJobsYAML(w io.Writer, cronJobs []batchv1.CronJob) error {
+	data, err := yaml.Marshal(summarizeCronJobs(cronJobs))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPrintCronJobs(t *testing.T) {
+	now := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	cronJobs := []batchv1.CronJob{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "hourly"},
+			Spec:       batchv1.CronJobSpec{Schedule: "0 * * * *"},
+			Status:     batchv1.CronJobStatus{LastScheduleTime: &metav1.Time{Time: now.Add(-5 * time.Minute)}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "suspended"},
+			Spec:       batchv1.CronJobSpec{Schedule: "0 0 * * *", Suspend: toPtr(true)},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := printCronJobs(&buf, cronJobs, now); err != nil {
+		t.Fatalf("printCronJobs got error: %v", err)
+	}
+
+	expect := `NAME        SCHEDULE    SUSPEND   LAST SCHEDULE
+hourly      0 * * * *   false     5m
+suspended   0 0 * * *   true      <none>
+`
+	if diff := cmp.Diff(expect, buf.String()); diff != "" {
+		t.Errorf("printCronJobs result diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestPrintCronJobsJSON(t *testing.T) {
+	lastSchedule := time.Date(2024, 4, 1, 11, 55, 0, 0, time.UTC)
+	cronJobs := []batchv1.CronJob{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "hourly"},
+			Spec:       batchv1.CronJobSpec{Schedule: "0 * * * *"},
+			Status:     batchv1.CronJobStatus{LastScheduleTime: &metav1.Time{Time: lastSchedule}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "suspended"},
+			Spec:       batchv1.CronJobSpec{Schedule: "0 0 * * *", Suspend: toPtr(true)},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := printCronJobsJSON(&buf, cronJobs); err != nil {
+		t.Fatalf("printCronJobsJSON got error: %v", err)
+	}
+
+	expect := `[
+  {
+    "name": "hourly",
+    "namespace": "default",
+    "schedule": "0 * * * *",
+    "suspend": false,
+    "lastSchedule": "2024-04-01T11:55:00Z"
+  },
+  {
+    "name": "suspended",
+    "namespace": "default",
+    "schedule": "0 0 * * *",
+    "suspend": true,
+    "lastSchedule": null
+  }
+]
+`
+	if diff := cmp.Diff(expect, buf.String()); diff != "" {
+		t.Errorf("printCronJobsJSON result diff (-expect, +got)\n%s", diff)
+	}
+
+	buf.Reset()
+	if err := printCronJobsJSON(&buf, nil); err != nil {
+		t.Fatalf("printCronJobsJSON got error: %v", err)
+	}
+	if buf.String() != "[]\n" {
+		t.Errorf(`printCronJobsJSON expected "[]" for no CronJobs, got %q`, buf.String())
+	}
+}
+
+func TestPrintCronJobsYAML(t *testing.T) {
+	cronJobs := []batchv1.CronJob{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "suspended"},
+			Spec:       batchv1.CronJobSpec{Schedule: "0 0 * * *", Suspend: toPtr(true)},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := printCronJobsYAML(&buf, cronJobs); err != nil {
+		t.Fatalf("printCronJobsYAML got error: %v", err)
+	}
+
+	expect := `- lastSchedule: null
+  name: suspended
+  namespace: default
+  schedule: 0 0 * * *
+  suspend: true
+`
+	if diff := cmp.Diff(expect, buf.String()); diff != "" {
+		t.Errorf("printCronJobsYAML result diff (-expect, +got)\n%s", diff)
+	}
+}
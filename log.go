@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// logLevel is set by -v. 0 prints nothing, and a larger level prints more:
+//
+//	1: kubeconfig and context
+//	2: namespace resolution
+//	3: server version and CronJob API version
+//	4: each API call
+var logLevel int
+
+// logOutput is where the debug logs are written. It is replaced in tests.
+var logOutput io.Writer = os.Stderr
+
+// logf prints the debug message when -v is level or higher.
+func logf(level int, format string, a ...any) {
+	if level > logLevel {
+		return
+	}
+	fmt.Fprintf(logOutput, "%s: "+format+"\n", append([]any{cmdName}, a...)...)
+}
+
+// loggingRoundTripper logs each API call at level 4.
+type loggingRoundTripper struct {
+	rt http.RoundTripper
+}
+
+func (l loggingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := l.rt.RoundTrip(req)
+	if err != nil {
+		logf(4, "%s %s failed in %s: %v", req.Method, req.URL, time.Since(start), err)
+		return nil, err
+	}
+	logf(4, "%s %s %s in %s", req.Method, req.URL, resp.Status, time.Since(start))
+	return resp, nil
+}
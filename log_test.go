@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// captureLog sets -v to level and returns the buffer which the logs are written to.
+func captureLog(t *testing.T, level int) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	oldLevel, oldOutput := logLevel, logOutput
+	logLevel, logOutput = level, &buf
+	t.Cleanup(func() { logLevel, logOutput = oldLevel, oldOutput })
+	return &buf
+}
+
+func TestLogf(t *testing.T) {
+	buf := captureLog(t, 2)
+
+	logf(2, "namespace %q", "default")
+	logf(3, "server version %s", "v1.29.0")
+
+	if got := buf.String(); got != "kj: namespace \"default\"\n" {
+		t.Errorf("logf output expected only the level 2 message, got %q", got)
+	}
+}
+
+func TestResolveNamespace_Log(t *testing.T) {
+	tests := map[string]struct {
+		level  int
+		logged bool
+	}{
+		"level 0 is silent": {level: 0},
+		"level 1":           {level: 1},
+		"level 2":           {level: 2, logged: true},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			buf := captureLog(t, tt.level)
+
+			if _, err := resolveNamespace("", "testdata/kubeconfig"); err != nil {
+				t.Fatalf("resolveNamespace got error: %v", err)
+			}
+			if logged := strings.Contains(buf.String(), `namespace "nsB"`); logged != tt.logged {
+				t.Errorf("namespace log expected %t, got %q", tt.logged, buf.String())
+			}
+		})
+	}
+}
@@ -4,26 +4,40 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/mattn/go-isatty"
 	"github.com/mattn/go-tty"
 	"github.com/mattn/go-tty/ttyutil"
 	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/client-go/util/homedir"
 	"sigs.k8s.io/yaml"
 )
@@ -41,7 +55,7 @@ func main() {
 }
 
 func run() int {
-	var kubeconfig *string
+	var a app
 	// default kubeconfig path is loaded in the following priority:
 	// 1. load environment variable KUBECONFIG exists
 	// 2. load $HOME/.kube/config
@@ -52,11 +66,73 @@ func run() int {
 		defaultKubeConfig = filepath.Join(home, ".kube", "config")
 	}
 	if defaultKubeConfig != "" {
-		kubeconfig = flag.String("kubeconfig", defaultKubeConfig, "(optional) absolute path to the kubeconfig file")
+		flag.StringVar(&a.kubeconfig, "kubeconfig", defaultKubeConfig, "(optional) absolute path to the kubeconfig file")
 	} else {
-		kubeconfig = flag.String("kubeconfig", "", "absolute path to the kubeconfig file")
+		flag.StringVar(&a.kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
 	}
-	filename := flag.String("f", "", "(optional) filename to save Job resource")
+	flag.StringVar(&a.clientOpts.impersonate.UserName, "as", "", "(optional) username to impersonate for the operation")
+	flag.StringVar(&a.clientOpts.cluster, "cluster", "", "(optional) name of the kubeconfig cluster to use instead of the one of the current context")
+	flag.StringVar(&a.clientOpts.user, "user", "", "(optional) name of the kubeconfig user to use instead of the one of the current context")
+	flag.BoolVar(&a.clientOpts.insecure, "insecure-skip-tls-verify", false, "(optional) don't verify the server certificate. This makes the connection insecure")
+	flag.StringVar(&a.clientOpts.proxyURL, "proxy-url", "", "(optional) URL of the HTTP or SOCKS5 proxy to reach the API server. kubectl is given it by HTTPS_PROXY")
+	flag.StringVar(&a.clientOpts.caFile, "ca-file", "", "(optional) path to the CA certificate file to verify the server certificate")
+	flag.Var((*stringsFlag)(&a.clientOpts.impersonate.Groups), "as-group", "(optional) group to impersonate for the operation (can be repeated)")
+	flag.StringVar(&a.filename, "f", "", "(optional) filename to save Job resource")
+	flag.Var(&a.creator.editorEnv, "editor-env", "(optional) KEY=VALUE environment variable passed to the editor (can be repeated)")
+	flag.StringVar(&a.opts.sourceCommit, "source-commit", os.Getenv("GIT_COMMIT"), "(optional) commit SHA recorded as the "+sourceCommitAnnotation+" annotation. Defaults to $GIT_COMMIT")
+	flag.BoolVar(&a.creator.smartApply, "smart-apply", false, "(optional) use `kubectl create` when the Job doesn't exist yet and `kubectl apply` when it already exists")
+	flag.BoolVar(&a.quiet, "quiet", false, "(optional) print only the name of the created Job and errors. Requires -yes")
+	flag.BoolVar(&a.creator.yes, "yes", false, "(optional) apply the Job without the confirmation after editing")
+	flag.BoolVar(&a.creator.noEdit, "no-edit", false, "(optional) apply the Job generated from the CronJob template without opening the editor")
+	flag.Var(&a.opts.images, "image", "(optional) container=image to override the image. The container can be omitted when the pod has only one container, and init:name selects an init container (can be repeated)")
+	flag.BoolVar(&a.opts.allContainers, "all-containers", false, "(optional) apply -image, -command, -args, -env, -env-from and -image-pull-policy to every container")
+	flag.StringVar(&a.opts.container, "container", "", "(optional) container name which -command, -args, -env, -env-from and -image-pull-policy are applied to. Required when the pod has multiple containers. Use init:name for an init container")
+	flag.Var(&a.opts.command, "command", "(optional) override the command of the container. Each flag is one element (can be repeated)")
+	flag.Var(&a.opts.args, "args", "(optional) override the args of the container. Each flag is one element (can be repeated)")
+	flag.IntVar(&a.opts.parallelism, "parallelism", 0, "(optional) override the parallelism of the Job")
+	flag.IntVar(&a.opts.completions, "completions", 0, "(optional) override the completions of the Job")
+	flag.StringVar(&a.opts.restartPolicy, "restart-policy", "", "(optional) override the restart policy of the pod. One of: Never, OnFailure")
+	flag.Var(&a.opts.nodeSelector, "node-selector", "(optional) key=value node selector added to the pod (can be repeated)")
+	flag.StringVar(&a.opts.nodeName, "node-name", "", "(optional) node name the pod is scheduled to")
+	flag.Var(&a.opts.envFrom, "env-from", "(optional) configmap:name or secret:name whose data is set as environment variables of the container (can be repeated)")
+	flag.StringVar(&a.opts.targetNamespace, "target-namespace", "", "(optional) namespace the Job is created in. The CronJob is read from the namespace given by the arguments, and the owner reference is dropped")
+	flag.Var(&a.opts.annotations, "annotation", "(optional) key=value annotation added to the Job (can be repeated)")
+	flag.Var(&a.opts.labels, "label", "(optional) key=value label added to the Job (can be repeated)")
+	flag.StringVar(&a.opts.imagePullPolicy, "image-pull-policy", "", "(optional) image pull policy of the container. One of: Always, IfNotPresent, Never")
+	flag.StringVar(&a.opts.serviceAccount, "service-account", "", "(optional) service account the pod runs as")
+	flag.Var(&a.opts.env, "env", "(optional) KEY=VALUE environment variable set on the container (can be repeated)")
+	flag.StringVar(&a.creator.output, "o", "", "(optional) output format after the Job is applied. One of: name")
+	flag.StringVar(&a.creator.fieldManager, "field-manager", cmdName, "(optional) name of the manager used to track field ownership")
+	flag.BoolVar(&a.creator.serverSide, "server-side", false, "(optional) apply the Job with server-side apply")
+	flag.BoolVar(&a.creator.forceConflicts, "force-conflicts", false, "(optional) take the ownership of the fields owned by other managers in server-side apply. Requires -server-side")
+	flag.DurationVar(&a.clientOpts.timeout, "timeout", 30*time.Second, "(optional) timeout for each Kubernetes API and kubectl call")
+	flag.BoolVar(&a.opts.generateName, "generate-name", false, "(optional) let the API server generate the Job name instead of the random suffix. The Job is created by `kubectl create`")
+	flag.BoolVar(&a.opts.noSuffix, "no-suffix", false, "(optional) use the CronJob name as the Job name without the random suffix. The Job is created by `kubectl create`, so it fails when the Job exists unless -replace is given")
+	flag.StringVar(&a.creator.kubectlPath, "kubectl-path", "kubectl", "(optional) kubectl command used to apply the Job")
+	flag.BoolVar(&a.creator.replace, "replace", false, "(optional) delete the Job with the same name if it exists, and create it again")
+	flag.DurationVar(&a.creator.confirmTimeout, "confirm-timeout", 0, "(optional) cancel the confirmation when nobody answers within the duration. 0 means waiting forever")
+	flag.StringVar(&a.creator.tempDir, "temp-dir", "", "(optional) directory of the temporary manifest to edit. Defaults to $TMPDIR or the OS temporary directory")
+	flag.StringVar(&a.creator.saveDir, "save-dir", "", "(optional) directory to save a copy of the applied manifest")
+	flag.StringVar(&a.creator.preApply, "pre-apply", "", "(optional) command run before the Job is applied. The Job is not applied when it fails")
+	flag.StringVar(&a.creator.postApply, "post-apply", "", "(optional) command run after the Job is applied")
+	flag.BoolVar(&a.creator.pruneTemplate, "prune-template", false, "(optional) remove the empty fields like \"resources: {}\" from the manifest to edit")
+	flag.StringVar(&a.creator.editFormat, "edit-format", "yaml", "(optional) format of the manifest opened in the editor. One of: yaml, json")
+	flag.StringVar(&a.creator.diffFormat, "diff-format", "", "(optional) print the changes made in the editor to stderr before applying, so that stdout has only the applied Job. One of: text, json")
+	flag.BoolVar(&a.creator.strictNamespace, "strict-namespace", false, "(optional) fail when the namespace is changed in the editor instead of warning")
+	flag.BoolVar(&a.showStatus, "show-status", false, "(optional) print the status of the Job after it is applied")
+	flag.BoolVar(&a.wait, "wait", false, "(optional) wait until the applied Job completes, and fail when the Job fails")
+	flag.DurationVar(&a.waitTimeout, "wait-timeout", 0, "(optional) give up -wait after the duration. 0 means waiting forever. -timeout is not applied to -wait")
+	flag.BoolVar(&a.watchEvents, "watch-events", false, "(optional) print the events of the Job and its pods after it is applied until the Job finishes")
+	showVersion := flag.Bool("version", false, "print the version")
+	flag.StringVar(&a.selector, "selector", "", "(optional) label selector to find the CronJob when the name is omitted. It must match exactly one CronJob")
+	flag.StringVar(&a.selector, "l", "", "(optional) shorthand for -selector")
+	flag.StringVar(&a.from, "from", "cronjob", "(optional) kind of the resource the Job is created from. One of: cronjob, job")
+	flag.BoolVar(&a.showCronJobInfo, "show-cronjob-info", true, "(optional) print the schedule, the last schedule time and the suspension of the CronJob before editing")
+	flag.StringVar(&a.timezone, "timezone", "Local", "(optional) IANA time zone name, e.g. Asia/Tokyo, used to print the last schedule time of the CronJob")
+	flag.StringVar(&a.outputDir, "output-dir", "", "(optional) write each Job to <dir>/<namespace>-<cronjob>.yaml instead of applying it")
+	flag.IntVar(&a.concurrency, "concurrency", 4, "(optional) number of the CronJobs processed at once with -output-dir or -no-edit -yes. The editor is always opened one by one")
+	flag.StringVar(&a.portForward, "port-forward", "", "(optional) LOCAL_PORT:POD_PORT to forward to the created Job's pod")
+	flag.IntVar(&logLevel, "v", 0, "(optional) log level for debugging. 1: kubeconfig, 2: namespace, 3: server version, 4: API calls")
 	flag.Usage = func() {
 		fmt.Printf(`%[1]s - create custom job from cronjob template
 
@@ -64,305 +140,1712 @@ Usage:
 	%[1]s namespace name
 	%[1]s namespace/name
 	%[1]s name
+	%[1]s namespace/name [namespace/name...]
+	%[1]s [namespace/]     pick the CronJob interactively
+	%[1]s -l selector [namespace/]
+	%[1]s -from=job namespace/name    clone the Job
+	%[1]s describe namespace/name
+	%[1]s list [-o table|json|yaml] [namespace]
+	%[1]s namespace [namespace/name]
+	%[1]s completion bash|zsh|fish
 
 Options:
 `, cmdName)
 		flag.PrintDefaults()
 	}
+	if err := loadConfigFile(flag.CommandLine, configPath(os.Getenv)); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
+		return exitStatusErr
+	}
 	flag.Parse()
 
-	clientset, err := newK8sClient(*kubeconfig)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s: failed to connect kubernetes (%v)\n", cmdName, err)
-		return exitStatusErr
+	args := flag.Args()
+	if len(args) > 0 {
+		// These don't need the cluster.
+		switch args[0] {
+		case "namespace":
+			return runNamespace(os.Stdout, a.kubeconfig, args[1:])
+		case "completion":
+			return runCompletion(os.Stdout, args[1:])
+		}
 	}
 
-	namespace, name, ok := getNamespaceAndName(flag.Args())
-	if !ok {
-		fmt.Fprintf(os.Stderr, "%s: argments are invalid\n", cmdName)
-		flag.Usage()
+	if err := a.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
 		return exitStatusErr
 	}
+	a.creator.generateName = a.opts.generateName
+	a.creator.noSuffix = a.opts.noSuffix
+	a.creator.info = os.Stderr
+	if a.quiet {
+		a.creator.setQuiet()
+	}
 
-	if namespace == "" {
-		kc, err := loadKubeconfig(*kubeconfig)
+	a.clientOpts.kubeconfig = a.kubeconfig
+	a.clientOpts.explicitKubeconfig = os.Getenv("KUBECONFIG") != "" || isFlagSet("kubeconfig")
+	config, err := newRestConfig(a.clientOpts)
+	if *showVersion {
+		// The server version is printed only when the cluster is reachable.
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
+			config = nil
 		}
-		namespace = kc.CurrentNamespace()
+		printVersion(os.Stdout, config)
+		return exitStatusOK
 	}
-
-	job, err := newJob(context.Background(), clientset, namespace, name)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
+		fmt.Fprintf(os.Stderr, "%s: failed to connect kubernetes (%v)\n", cmdName, err)
 		return exitStatusErr
 	}
+	if a.clientOpts.insecure {
+		fmt.Fprintf(os.Stderr, "%s: warning: the server certificate is not verified. The connection is insecure\n", cmdName)
+	}
 
-	if err = createJobWithFileName(filename, job); err != nil {
-		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: failed to connect kubernetes (%v)\n", cmdName, err)
 		return exitStatusErr
 	}
 
+	if len(args) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), a.clientOpts.timeout)
+		defer cancel()
+		switch args[0] {
+		case "describe":
+			return runDescribe(ctx, clientset, a.kubeconfig, a.opts, args[1:])
+		case "list":
+			return runList(ctx, clientset, a.kubeconfig, args[1:])
+		case completeCommand:
+			return runComplete(ctx, os.Stdout, clientset, a.kubeconfig, args[1:])
+		}
+	}
+
+	if err := a.createJobs(clientset, config, args); err != nil {
+		printError(os.Stderr, err)
+		if errors.Is(err, errInvalidArguments) {
+			flag.Usage()
+		}
+		return exitStatusErr
+	}
 	return exitStatusOK
 }
 
-func newK8sClient(kubeconfig string) (*kubernetes.Clientset, error) {
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
-	if err != nil {
-		return nil, err
+// printError prints the error with the command name. Joined errors are printed one per line.
+func printError(w io.Writer, err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			printError(w, err)
+		}
+		return
 	}
+	fmt.Fprintf(w, "%s: %v\n", cmdName, err)
+}
 
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, err
+var errInvalidArguments = errors.New("argments are invalid")
+
+// app creates the Jobs from the CronJobs with the options given by the flags.
+type app struct {
+	kubeconfig  string
+	clientOpts  clientOptions
+	opts        jobOptions
+	creator     jobCreator
+	filename    string
+	quiet       bool
+	showStatus  bool
+	watchEvents bool
+	wait        bool
+	waitTimeout time.Duration
+	portForward string
+	outputDir   string
+	selector    string
+	// from is the kind of the source resource, "cronjob" or "job".
+	from string
+	// showCronJobInfo prints the schedule of the CronJob before editing.
+	showCronJobInfo bool
+	// timezone is the location name passed to time.LoadLocation.
+	timezone string
+	// concurrency is the number of the CronJobs processed at once when no terminal is involved.
+	concurrency int
+}
+
+// validate checks the combination of the flags before connecting to the cluster.
+func (a *app) validate() error {
+	c := &a.creator
+	if c.output != "" && c.output != "name" {
+		return fmt.Errorf("unknown output format %q", c.output)
+	}
+	if a.quiet && !c.yes {
+		return errors.New("-quiet requires -yes because the confirmation can't be answered quietly")
+	}
+	if c.editFormat != "" && c.editFormat != "yaml" && c.editFormat != "json" {
+		return fmt.Errorf("unknown edit format %q", c.editFormat)
+	}
+	if c.diffFormat != "" && c.diffFormat != "text" && c.diffFormat != "json" {
+		return fmt.Errorf("unknown diff format %q", c.diffFormat)
+	}
+	if c.forceConflicts && !c.serverSide {
+		return errors.New("-force-conflicts requires -server-side")
+	}
+	if c.serverSide && c.smartApply {
+		return errors.New("-server-side can't be used with -smart-apply")
+	}
+	if a.opts.generateName && (c.serverSide || c.smartApply) {
+		return errors.New("-generate-name can't be used with -server-side or -smart-apply")
+	}
+	if a.opts.noSuffix && (c.serverSide || c.smartApply || a.opts.generateName) {
+		return errors.New("-no-suffix can't be used with -server-side, -smart-apply or -generate-name")
+	}
+	if c.replace && (c.serverSide || c.smartApply || a.opts.generateName) {
+		return errors.New("-replace can't be used with -server-side, -smart-apply or -generate-name")
+	}
+	if a.from != "" && a.from != "cronjob" && a.from != "job" {
+		return fmt.Errorf("unknown source kind %q for -from", a.from)
+	}
+	if a.from == "job" && a.selector != "" {
+		return errors.New("-selector can't be used with -from=job")
+	}
+	if _, err := time.LoadLocation(a.timezone); err != nil {
+		return fmt.Errorf("unknown time zone %q for -timezone: %w", a.timezone, err)
+	}
+	if c.tempDir != "" {
+		if err := checkWritableDir(c.tempDir); err != nil {
+			return fmt.Errorf("-temp-dir is not usable: %w", err)
+		}
+	}
+	if c.saveDir != "" {
+		if err := prepareSaveDir(c.saveDir); err != nil {
+			return err
+		}
+	}
+	if err := a.opts.validate(); err != nil {
+		return err
+	}
+	if a.portForward != "" {
+		if err := validatePortForward(a.portForward); err != nil {
+			return err
+		}
+	}
+	if (a.watchEvents || a.wait) && a.portForward != "" {
+		return errors.New("-watch-events and -wait can't be used with -port-forward")
 	}
-	return clientset, nil
+	if a.waitTimeout < 0 {
+		return errors.New("-wait-timeout must not be negative")
+	}
+	if a.concurrency < 0 {
+		return errors.New("-concurrency must not be negative")
+	}
+	if a.waitTimeout > 0 && !a.wait {
+		return errors.New("-wait-timeout requires -wait")
+	}
+	if a.outputDir != "" && (a.filename != "" || a.portForward != "" || a.showStatus || a.watchEvents || a.wait) {
+		return errors.New("-output-dir can't be used with -f, -port-forward, -show-status, -watch-events or -wait because the jobs are not applied")
+	}
+	return nil
 }
 
-func getNamespaceAndName(s []string) (namespace, name string, ok bool) {
-	if len(s) == 0 || len(s) > 2 {
-		return "", "", false
+// createJobs creates the Jobs from the CronJobs in args. The errors of the CronJobs are joined.
+func (a *app) createJobs(clientset kubernetes.Interface, config *rest.Config, args []string) error {
+	targets, ok := getTargets(args)
+	if len(args) == 0 {
+		targets, ok = []target{{}}, true
+	}
+	if !ok {
+		return errInvalidArguments
+	}
+	// Without the name, the CronJob is found by -selector or picked interactively.
+	pick := targets[0].name == "" && a.selector == ""
+	if pick && a.from == "job" {
+		return errors.New("the job name is required with -from=job")
+	}
+	if pick && !isatty.IsTerminal(os.Stdin.Fd()) {
+		return errInvalidArguments
+	}
+	if len(targets) > 1 && (a.filename != "" || a.portForward != "") {
+		return errors.New("-f and -port-forward can't be used with multiple cronjobs")
+	}
+	if a.outputDir != "" {
+		return a.exportJobs(clientset, targets)
 	}
 
-	if len(s) == 2 {
-		return s[0], s[1], true
+	var err error
+	if a.creator.applier == nil {
+		a.creator.kubectlPath, err = lookKubectl(a.creator.kubectlPath, exec.LookPath)
+		if err != nil {
+			return err
+		}
 	}
 
-	s = strings.Split(s[0], "/")
-	if len(s) > 2 {
-		return "", "", false
+	if a.creator.needsTTY(pick) {
+		// Check the terminal before calling the API so that non-interactive runs fail fast.
+		a.creator.tty, err = openInteractiveTTY(tty.Open)
+		if err != nil {
+			return err
+		}
+		defer a.creator.tty.Close()
 	}
 
-	if len(s) == 1 {
-		return "", s[0], true
+	a.creator.clientset = clientset
+	a.creator.kubectlFlags = a.clientOpts.kubectlFlags()
+	a.creator.kubectlEnv = a.clientOpts.kubectlEnv()
+	a.creator.timeout = a.clientOpts.timeout
+
+	ga, err := serverCronJobGA(clientset)
+	if err != nil {
+		return err
 	}
 
-	return s[0], s[1], true
+	create := func(t target) error { return a.createJob(clientset, config, ga, t) }
+	if a.creator.noEdit && a.creator.yes {
+		return forEachTarget(targets, a.concurrency, create)
+	}
+	// Each Job is edited one by one because the editor occupies the terminal.
+	return forEachTarget(targets, 1, create)
+}
+
+// forEachTarget calls f for the targets, at most limit at once.
+// The errors are joined in the order of the targets.
+func forEachTarget(targets []target, limit int, f func(target) error) error {
+	errs := make([]error, len(targets))
+	sem := make(chan struct{}, max(limit, 1))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = f(t)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
 }
 
-func newJob(ctx context.Context, clientset *kubernetes.Clientset, namespace, name string) (*batchv1.Job, error) {
-	jobSpec, ownerRef, err := newJobTemplate(ctx, clientset, namespace, name)
+// exportJobs writes the Jobs to <outputDir>/<namespace>-<cronjob name>.yaml without applying them.
+func (a *app) exportJobs(clientset kubernetes.Interface, targets []target) error {
+	ga, err := serverCronJobGA(clientset)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if err := os.MkdirAll(a.outputDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create -output-dir: %w", err)
+	}
+
+	return forEachTarget(targets, a.concurrency, func(t target) error {
+		path, err := a.exportJob(clientset, ga, t)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(a.creator.info, "wrote %s\n", path)
+		return nil
+	})
+}
+
+func (a *app) exportJob(clientset kubernetes.Interface, ga bool, t target) (string, error) {
+	if t.name == "" {
+		return "", errors.New("the cronjob name is required with -output-dir")
 	}
 
-	suffix, err := randStr(6)
+	ctx, cancel := context.WithTimeout(context.Background(), a.clientOpts.timeout)
+	defer cancel()
+
+	namespace, err := resolveNamespace(t.namespace, a.kubeconfig)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	job := &batchv1.Job{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "batch/v1",
-			Kind:       "Job",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace:       namespace,
-			Name:            fmt.Sprintf("%s-%s", name, suffix),
-			OwnerReferences: []metav1.OwnerReference{ownerRef},
-		},
-		Spec: jobSpec,
+	job, err := a.newJob(ctx, clientset, ga, namespace, t.name)
+	if err != nil {
+		return "", err
 	}
-	return job, nil
+	data, err := jobToYaml(job, a.creator.pruneTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(a.outputDir, fmt.Sprintf("%s-%s.yaml", job.Namespace, t.name))
+	f, err := createPrivateFile(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
 }
 
-func newJobTemplate(ctx context.Context, clientset *kubernetes.Clientset, namespace, name string) (jobSpec batchv1.JobSpec, ownerRef metav1.OwnerReference, err error) {
-	v, err := clientset.ServerVersion()
+// now returns the current time in -timezone.
+func (a *app) now() time.Time {
+	loc, err := time.LoadLocation(a.timezone)
 	if err != nil {
-		return jobSpec, ownerRef, fmt.Errorf("failed to get serverVersion: %w", err)
+		// Unreachable because validate checks the name.
+		return time.Now()
 	}
+	return time.Now().In(loc)
+}
 
-	// When kubernetes version is 1.21 or higher, use batchv1.CronJob.
-	// Otherwise, use batchv1beta1.CronJob
-	if isCronJobGA(v) {
-		cj, err := clientset.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+// newJob builds the Job from the CronJob, or from the Job with -from=job.
+func (a *app) newJob(ctx context.Context, clientset kubernetes.Interface, ga bool, namespace, name string) (*batchv1.Job, error) {
+	if a.from == "job" {
+		src, err := clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
 		if err != nil {
-			return jobSpec, ownerRef, err
-		}
-		ownerRef := metav1.OwnerReference{
-			APIVersion:         "batch/v1",
-			Kind:               "CronJob",
-			Name:               cj.GetName(),
-			UID:                cj.GetUID(),
-			BlockOwnerDeletion: toPtr(true),
+			return nil, err
 		}
-		return cj.Spec.JobTemplate.Spec, ownerRef, nil
+		return newJobFromJob(src, a.opts)
 	}
 
-	cj, err := clientset.BatchV1beta1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	cj, err := findCronJob(ctx, clientset, ga, namespace, name)
 	if err != nil {
-		return jobSpec, ownerRef, err
+		return nil, err
 	}
-	ownerRef = metav1.OwnerReference{
-		APIVersion:         "batch/v1beta1",
-		Kind:               "CronJob",
-		Name:               cj.GetName(),
-		UID:                cj.GetUID(),
-		BlockOwnerDeletion: toPtr(true),
+	if a.showCronJobInfo && a.outputDir == "" {
+		fmt.Fprintln(a.creator.info, formatCronJobInfo(cj, a.now()))
 	}
-	return cj.Spec.JobTemplate.Spec, ownerRef, nil
+	return newJob(cj, a.opts)
 }
 
-func isCronJobGA(v *version.Info) bool {
-	if (v.Major == "1" && v.Minor >= "21") || v.Major > "1" {
-		return true
+// createJob creates the Job from the CronJob of t.
+func (a *app) createJob(clientset kubernetes.Interface, config *rest.Config, ga bool, t target) error {
+	// The timeout covers the API calls before editing. Applying has its own timeout
+	// because the user may spend any amount of time in the editor.
+	ctx, cancel := context.WithTimeout(context.Background(), a.clientOpts.timeout)
+	defer cancel()
+
+	namespace, err := resolveNamespace(t.namespace, a.kubeconfig)
+	if err != nil {
+		return err
 	}
-	return false
-}
 
-func randStr(n int) (string, error) {
-	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
+	name := t.name
+	switch {
+	case name != "":
+	case a.selector != "":
+		name, err = selectCronJob(ctx, clientset, ga, namespace, a.selector)
+	default:
+		name, err = pickCronJob(ctx, clientset, ga, a.creator.tty, namespace)
+	}
+	if err != nil {
+		return err
+	}
 
-	b := make([]byte, n)
-	if _, err := rand.Read(b); err != nil {
-		return "", err
+	job, err := a.newJob(ctx, clientset, ga, namespace, name)
+	if err != nil {
+		return err
 	}
 
-	var builder strings.Builder
-	for _, v := range b {
-		builder.WriteByte(letters[int(v)%len(letters)])
+	applied, err := a.creator.createWithFileName(a.filename, job)
+	if err != nil {
+		return err
+	}
+	if applied == nil {
+		return nil
+	}
+	if applied.Namespace == "" {
+		applied.Namespace = namespace
 	}
-	return builder.String(), nil
-}
 
-func createJobWithFileName(filename *string, job *batchv1.Job) error {
-	var f *os.File
-	var err error
-	if filename == nil || *filename == "" {
-		f, err = os.CreateTemp("", "kj.*.yaml")
+	if a.showStatus {
+		ctx, cancel := context.WithTimeout(context.Background(), a.clientOpts.timeout)
+		defer cancel()
+		status, err := pollJobStatus(ctx, clientset, applied.Namespace, applied.Name)
 		if err != nil {
 			return err
 		}
-		defer os.Remove(f.Name())
-	} else {
-		f, err = os.Create(*filename)
+		fmt.Fprintf(os.Stderr, "job.batch/%s: %s\n", applied.Name, formatJobStatus(status))
+	}
+
+	if a.watchEvents {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := watchJobEvents(ctx, os.Stderr, clientset, applied.Namespace, applied.Name); err != nil {
+			return err
+		}
+	}
+
+	if a.wait {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		finished, err := waitForJob(ctx, clientset, applied.Namespace, applied.Name, a.waitTimeout)
 		if err != nil {
 			return err
 		}
+		fmt.Fprintf(os.Stderr, "job.batch/%s: %s\n", applied.Name, formatJobStatus(finished.Status))
 	}
 
-	return createJob(f, job)
+	if a.portForward != "" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := portForwardJob(ctx, config, clientset, applied.Namespace, applied.Name, a.portForward); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func confirmByUser(tty *tty.TTY) (bool, error) {
-	fmt.Fprint(tty.Output(), "Do you want to create a job with the change you just made? [y/n]\n")
-
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT)
-	defer signal.Stop(sigs)
-
-	answerCh := make(chan string)
-	errCh := make(chan error)
+type clientOptions struct {
+	kubeconfig         string
+	explicitKubeconfig bool
+	impersonate        rest.ImpersonationConfig
+	timeout            time.Duration
+	// cluster and user override the ones of the current context.
+	cluster string
+	user    string
+	// insecure skips the verification of the server certificate.
+	insecure bool
+	// caFile is the CA certificate trusted instead of the one in kubeconfig.
+	caFile string
+	// proxyURL is the proxy used instead of the environment variables.
+	proxyURL string
+}
 
-	go func() {
-		for {
-			answer, err := ttyutil.ReadLine(tty)
-			if err != nil {
-				if errors.Is(err, io.EOF) {
-					continue
-				}
-				errCh <- err
-				return
-			}
-			answerCh <- answer
+// newRestConfig builds the config from kubeconfig.
+// When kubeconfig is not specified explicitly and doesn't exist, the in-cluster config is used.
+func newRestConfig(o clientOptions) (*rest.Config, error) {
+	if o.caFile != "" {
+		if o.insecure {
+			return nil, errors.New("-ca-file can't be used with -insecure-skip-tls-verify")
 		}
-	}()
+		// Check it here because client-go reads the file only when connecting.
+		f, err := os.Open(o.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read -ca-file: %w", err)
+		}
+		f.Close()
+	}
+	var proxy *url.URL
+	if o.proxyURL != "" {
+		var err error
+		proxy, err = parseProxyURL(o.proxyURL)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	for {
-		select {
-		case <-sigs:
-			return false, nil
-		case answer := <-answerCh:
-			answer = strings.ToLower(strings.TrimSpace(answer))
-			switch answer {
-			case "y":
-				return true, nil
-			case "n", "":
-				return false, nil
-			default:
-				fmt.Fprint(tty.Output(), "Please answer y or n: \n")
+	var config *rest.Config
+	var err error
+	if !o.explicitKubeconfig && !kubeconfigExists(o.kubeconfig) {
+		logf(1, "using in-cluster config because kubeconfig %s doesn't exist", o.kubeconfig)
+		config, err = rest.InClusterConfig()
+	} else {
+		logf(1, "using kubeconfig %s", o.kubeconfig)
+		loadingRules := &clientcmd.ClientConfigLoadingRules{Precedence: filepath.SplitList(o.kubeconfig)}
+		overrides := &clientcmd.ConfigOverrides{
+			Context: clientcmdapi.Context{Cluster: o.cluster, AuthInfo: o.user},
+		}
+		clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+		if err := validateContextOverrides(clientConfig, o.cluster, o.user); err != nil {
+			return nil, err
+		}
+		if logLevel >= 1 {
+			if raw, err := clientConfig.RawConfig(); err == nil {
+				logf(1, "using context %q", raw.CurrentContext)
 			}
-		case err := <-errCh:
-			return false, err
 		}
+		config, err = clientConfig.ClientConfig()
 	}
-}
-
-func createJob(f *os.File, job *batchv1.Job) error {
-	data, err := jobToYaml(job)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = f.Write(data)
-	if err != nil {
-		return err
+	config.Impersonate = o.impersonate
+	if o.insecure {
+		// client-go rejects the config which has both Insecure and a CA.
+		config.TLSClientConfig.Insecure = true
+		config.TLSClientConfig.CAData = nil
+		config.TLSClientConfig.CAFile = ""
 	}
-
-	if err = f.Close(); err != nil {
-		return err
+	if o.caFile != "" {
+		// CAData takes precedence over CAFile in client-go.
+		config.TLSClientConfig.CAData = nil
+		config.TLSClientConfig.CAFile = o.caFile
 	}
+	if proxy != nil {
+		config.Proxy = http.ProxyURL(proxy)
+	}
+	// Timeout also bounds the discovery calls like ServerVersion, which don't accept a context.
+	config.Timeout = o.timeout
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper { return loggingRoundTripper{rt: rt} })
+	return config, nil
+}
 
-	tty, err := tty.Open()
+// validateContextOverrides checks the clusters and users of -cluster and -user exist in the kubeconfig.
+func validateContextOverrides(clientConfig clientcmd.ClientConfig, cluster, user string) error {
+	if cluster == "" && user == "" {
+		return nil
+	}
+	raw, err := clientConfig.RawConfig()
 	if err != nil {
 		return err
 	}
-	defer tty.Close()
-
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = "vi"
+	if _, ok := raw.Clusters[cluster]; cluster != "" && !ok {
+		return fmt.Errorf("cluster %q is not found in kubeconfig (available: %s)", cluster, strings.Join(sortedKeys(raw.Clusters), ", "))
+	}
+	if _, ok := raw.AuthInfos[user]; user != "" && !ok {
+		return fmt.Errorf("user %q is not found in kubeconfig (available: %s)", user, strings.Join(sortedKeys(raw.AuthInfos), ", "))
 	}
-	editorWithArgs := strings.Fields(editor)
-	editorWithArgs = append(editorWithArgs, f.Name())
+	return nil
+}
 
-	cmd := exec.Command(editorWithArgs[0], editorWithArgs[1:]...)
-	cmd.Stdin = tty.Input()
-	cmd.Stdout = tty.Output()
-	cmd.Stderr = tty.Output()
-	if err := cmd.Run(); err != nil {
-		return err
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
+	slices.Sort(keys)
+	return keys
+}
 
-	confirmed, err := confirmByUser(tty)
+// parseProxyURL parses -proxy-url, which must have a supported scheme and a host.
+func parseProxyURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("-proxy-url %q is invalid: %w", s, err)
 	}
-	if !confirmed {
-		fmt.Println("canceled")
-		return nil
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("-proxy-url %q must start with http://, https:// or socks5://", s)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("-proxy-url %q has no host", s)
 	}
+	return u, nil
+}
 
-	cmd = exec.Command("kubectl", "apply", "-f", f.Name())
-	cmd.Stdin = tty.Input()
-	cmd.Stdout = tty.Output()
-	cmd.Stderr = tty.Output()
-	if err := cmd.Run(); err != nil {
-		return err
+// kubectlEnv returns the environment variables to pass the same options to kubectl,
+// which has no flag for the proxy.
+func (o clientOptions) kubectlEnv() []string {
+	if o.proxyURL == "" {
+		return nil
 	}
-	return nil
+	return []string{"HTTPS_PROXY=" + o.proxyURL, "HTTP_PROXY=" + o.proxyURL}
 }
 
-func jobToYaml(job *batchv1.Job) ([]byte, error) {
-	// Marshal with ownerReferences commented out
-	ownerRefs, err := yaml.Marshal(map[string]any{"ownerReferences": job.ObjectMeta.OwnerReferences})
-	if err != nil {
-		return nil, err
+// kubectlFlags returns the flags to pass the same options to kubectl.
+func (o clientOptions) kubectlFlags() []string {
+	var flags []string
+	// The kubeconfig is passed so that kubectl resolves -cluster and -user in the same file.
+	// A list in $KUBECONFIG can't be a flag, and kubectl reads the variable by itself.
+	if o.explicitKubeconfig && o.kubeconfig != "" && !strings.Contains(o.kubeconfig, string(filepath.ListSeparator)) {
+		flags = append(flags, "--kubeconfig", o.kubeconfig)
+	}
+	if o.cluster != "" {
+		flags = append(flags, "--cluster", o.cluster)
 	}
+	if o.user != "" {
+		flags = append(flags, "--user", o.user)
+	}
+	if o.insecure {
+		flags = append(flags, "--insecure-skip-tls-verify")
+	}
+	if o.caFile != "" {
+		flags = append(flags, "--certificate-authority", o.caFile)
+	}
+	if o.impersonate.UserName != "" {
+		flags = append(flags, "--as", o.impersonate.UserName)
+	}
+	for _, g := range o.impersonate.Groups {
+		flags = append(flags, "--as-group", g)
+	}
+	return flags
+}
 
-	job.ObjectMeta.OwnerReferences = nil
-	data, err := yaml.Marshal(job)
-	if err != nil {
-		return nil, err
+func isFlagSet(name string) bool {
+	var set bool
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+type target struct {
+	namespace, name string
+}
+
+// getTargets parses the CronJobs in the arguments.
+// Two arguments without "/" are "namespace name" for compatibility,
+// so each of the multiple CronJobs must be "namespace/name". Otherwise adding
+// a name to "namespace name" would silently change the meaning of the first one.
+// A single "namespace/" is a target without the name, which is picked later.
+func getTargets(args []string) ([]target, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	if len(args) == 1 {
+		namespace, name, ok := getNamespaceAndName(args)
+		return []target{{namespace: namespace, name: name}}, ok && (namespace != "" || name != "")
+	}
+	if len(args) == 2 && !strings.Contains(args[0], "/") && !strings.Contains(args[1], "/") {
+		return []target{{namespace: args[0], name: args[1]}}, true
 	}
 
-	commentForOwnerRefs := "  # "
-	commentedOwnerRefs := commentForOwnerRefs + strings.ReplaceAll(string(ownerRefs), "\n", "\n"+commentForOwnerRefs)
-	commentedOwnerRefs = strings.TrimSuffix(commentedOwnerRefs, commentForOwnerRefs)
-	namespaceInd := bytes.Index(data, []byte("namespace: "))
-	namespaceLineInd := bytes.Index(data[namespaceInd:], []byte("\n")) + namespaceInd
+	targets := make([]target, 0, len(args))
+	for _, arg := range args {
+		namespace, name, ok := getNamespaceAndName([]string{arg})
+		if !ok || namespace == "" || name == "" {
+			return nil, false
+		}
+		targets = append(targets, target{namespace: namespace, name: name})
+	}
+	return targets, true
+}
 
-	data = slices.Insert(data, namespaceLineInd+1, []byte(commentedOwnerRefs)...)
-	return data, nil
+func getNamespaceAndName(s []string) (namespace, name string, ok bool) {
+	if len(s) == 0 || len(s) > 2 {
+		return "", "", false
+	}
+
+	if len(s) == 2 {
+		return s[0], s[1], true
+	}
+
+	s = strings.Split(s[0], "/")
+	if len(s) > 2 {
+		return "", "", false
+	}
+
+	if len(s) == 1 {
+		return "", s[0], true
+	}
+
+	return s[0], s[1], true
+}
+
+// newJob builds the Job from the template of the CronJob.
+func newJob(cj *batchv1.CronJob, opts jobOptions) (*batchv1.Job, error) {
+	jobSpec, ownerRef := newJobTemplate(cj)
+	return buildJob(cj.Namespace, cj.Name, jobSpec, []metav1.OwnerReference{ownerRef}, opts)
+}
+
+// jobControllerLabels are set by the Job controller to bind the pods to the Job.
+// They must not be copied to another Job because the selector is generated from the new UID.
+var jobControllerLabels = []string{
+	"controller-uid",
+	"batch.kubernetes.io/controller-uid",
+	jobNameLabel,
+	"batch.kubernetes.io/job-name",
+}
+
+// newJobFromJob creates a copy of src with a fresh name. The selector and the pod labels
+// bound to src are removed by normalizeClonedSpec, and no owner reference is set.
+func newJobFromJob(src *batchv1.Job, opts jobOptions) (*batchv1.Job, error) {
+	spec := *src.Spec.DeepCopy()
+	normalizeClonedSpec(&spec)
+	return buildJob(src.Namespace, src.Name, spec, nil, opts)
+}
+
+// normalizeClonedSpec clears the selector of the copied spec so that the API server
+// generates a new one. Otherwise the apply fails because the selector is immutable
+// or doesn't match the template labels of the new Job.
+func normalizeClonedSpec(spec *batchv1.JobSpec) {
+	spec.Selector = nil
+	spec.ManualSelector = nil
+
+	labels := make(map[string]string, len(spec.Template.Labels))
+	for k, v := range spec.Template.Labels {
+		labels[k] = v
+	}
+	for _, l := range jobControllerLabels {
+		delete(labels, l)
+	}
+	spec.Template.Labels = labels
+}
+
+// buildJob creates the Job named after name with jobSpec.
+func buildJob(namespace, name string, jobSpec batchv1.JobSpec, ownerRefs []metav1.OwnerReference, opts jobOptions) (*batchv1.Job, error) {
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "batch/v1",
+			Kind:       "Job",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       namespace,
+			OwnerReferences: ownerRefs,
+		},
+		Spec: jobSpec,
+	}
+	if opts.generateName {
+		// The API server appends the random suffix.
+		job.GenerateName = name + "-"
+	} else {
+		if opts.noSuffix {
+			job.Name = name
+		} else {
+			suffix, err := randStr(6)
+			if err != nil {
+				return nil, err
+			}
+			job.Name = jobName(name, suffix)
+		}
+
+		// The pod template labels of the source are kept, and "job-name" is added explicitly
+		// so that the pods can be found by the label, e.g. kubectl logs -l job-name=<name>.
+		labels := make(map[string]string, len(job.Spec.Template.Labels)+1)
+		for k, v := range job.Spec.Template.Labels {
+			labels[k] = v
+		}
+		labels[jobNameLabel] = job.Name
+		job.Spec.Template.Labels = labels
+	}
+	if err := validateJobName(job, name); err != nil {
+		return nil, err
+	}
+	if err := opts.apply(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// validateJobName checks the generated name so that an invalid source name is reported
+// here instead of as an apply failure after editing.
+func validateJobName(job *batchv1.Job, source string) error {
+	name := job.Name
+	if name == "" {
+		// "a" stands for the suffix which the API server appends to generateName.
+		name = job.GenerateName + "a"
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("the job name %q generated from %q is invalid: %s", name, source, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// jobNameLabel is the pod label which the Job controller also sets.
+const jobNameLabel = "job-name"
+
+// refreshJobNameLabel sets the job-name label to the Job name renamed in the editor,
+// and reports whether the label is changed. The label removed in the editor is left to the Job controller.
+func refreshJobNameLabel(job *batchv1.Job) bool {
+	label, ok := job.Spec.Template.Labels[jobNameLabel]
+	if !ok || job.Name == "" || label == job.Name {
+		return false
+	}
+	job.Spec.Template.Labels[jobNameLabel] = job.Name
+	return true
+}
+
+// maxJobNameLength is the limit of the Job name because it is used as the "job-name" label value.
+const maxJobNameLength = 63
+
+// jobName returns "<prefix>-<suffix>", truncating the prefix to keep the name within maxJobNameLength.
+func jobName(prefix, suffix string) string {
+	if maxPrefix := maxJobNameLength - len(suffix) - 1; len(prefix) > maxPrefix {
+		prefix = strings.TrimRight(prefix[:maxPrefix], "-.")
+	}
+	return fmt.Sprintf("%s-%s", prefix, suffix)
+}
+
+// findCronJob returns the CronJob. When it is not found, the similar names are suggested in the error.
+func findCronJob(ctx context.Context, clientset kubernetes.Interface, ga bool, namespace, name string) (*batchv1.CronJob, error) {
+	cj, err := getCronJob(ctx, clientset, ga, namespace, name)
+	if apierrors.IsNotFound(err) {
+		return nil, withSuggestions(ctx, clientset, ga, namespace, name, err)
+	}
+	return cj, err
+}
+
+func newJobTemplate(cj *batchv1.CronJob) (batchv1.JobSpec, metav1.OwnerReference) {
+	ownerRef := metav1.OwnerReference{
+		APIVersion:         cj.APIVersion,
+		Kind:               "CronJob",
+		Name:               cj.GetName(),
+		UID:                cj.GetUID(),
+		BlockOwnerDeletion: toPtr(true),
+	}
+	return cj.Spec.JobTemplate.Spec, ownerRef
+}
+
+// serverCronJobGA reports whether the server serves batchv1.CronJob.
+// It is called once per run because ServerVersion is a discovery call.
+func serverCronJobGA(clientset kubernetes.Interface) (bool, error) {
+	v, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return false, fmt.Errorf("failed to get serverVersion: %w", err)
+	}
+	ga := isCronJobGA(v)
+	apiVersion := "batch/v1beta1"
+	if ga {
+		apiVersion = "batch/v1"
+	}
+	logf(3, "server version is %s, using %s CronJob", v.GitVersion, apiVersion)
+	return ga, nil
+}
+
+// getCronJob returns the CronJob with its APIVersion set.
+// When ga is false, batchv1beta1.CronJob is fetched and converted to batchv1.CronJob.
+func getCronJob(ctx context.Context, clientset kubernetes.Interface, ga bool, namespace, name string) (*batchv1.CronJob, error) {
+	// When kubernetes version is 1.21 or higher, use batchv1.CronJob.
+	// Otherwise, use batchv1beta1.CronJob, falling back to batchv1.CronJob when it is not served.
+	if !ga {
+		cj, err := clientset.BatchV1beta1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if !isAPINotServed(err) {
+			if err != nil {
+				return nil, err
+			}
+			return cronJobFromV1beta1(cj), nil
+		}
+		logf(3, "batch/v1beta1 CronJob is not served, retrying with batch/v1")
+	}
+
+	cj, err := clientset.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	cj.APIVersion = "batch/v1"
+	cj.Kind = "CronJob"
+	return cj, nil
+}
+
+// listCronJobs returns the CronJobs in the namespace matching the label selector in the same way as getCronJob.
+// An empty selector matches all CronJobs.
+func listCronJobs(ctx context.Context, clientset kubernetes.Interface, ga bool, namespace, selector string) ([]batchv1.CronJob, error) {
+	opts := metav1.ListOptions{LabelSelector: selector}
+	if !ga {
+		l, err := clientset.BatchV1beta1().CronJobs(namespace).List(ctx, opts)
+		if !isAPINotServed(err) {
+			if err != nil {
+				return nil, err
+			}
+			cronJobs := make([]batchv1.CronJob, 0, len(l.Items))
+			for i := range l.Items {
+				cronJobs = append(cronJobs, *cronJobFromV1beta1(&l.Items[i]))
+			}
+			return cronJobs, nil
+		}
+		logf(3, "batch/v1beta1 CronJob is not served, retrying with batch/v1")
+	}
+
+	l, err := clientset.BatchV1().CronJobs(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return l.Items, nil
+}
+
+// isAPINotServed reports whether err means that the API itself is missing on the server,
+// e.g. batch/v1beta1 on 1.25 or later. The NotFound of a missing object has the name in the details.
+func isAPINotServed(err error) bool {
+	if meta.IsNoMatchError(err) {
+		return true
+	}
+	var status apierrors.APIStatus
+	if !apierrors.IsNotFound(err) || !errors.As(err, &status) {
+		return false
+	}
+	details := status.Status().Details
+	return details == nil || details.Name == ""
+}
+
+func cronJobFromV1beta1(cj *batchv1beta1.CronJob) *batchv1.CronJob {
+	return &batchv1.CronJob{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "batch/v1beta1",
+			Kind:       "CronJob",
+		},
+		ObjectMeta: cj.ObjectMeta,
+		Spec: batchv1.CronJobSpec{
+			Schedule: cj.Spec.Schedule,
+			TimeZone: cj.Spec.TimeZone,
+			Suspend:  cj.Spec.Suspend,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: cj.Spec.JobTemplate.ObjectMeta,
+				Spec:       cj.Spec.JobTemplate.Spec,
+			},
+		},
+		Status: batchv1.CronJobStatus{
+			LastScheduleTime: cj.Status.LastScheduleTime,
+		},
+	}
+}
+
+func isCronJobGA(v *version.Info) bool {
+	major, minor := versionNumber(v.Major), versionNumber(v.Minor)
+	if v.Major == "" {
+		// Some distributions report only gitVersion like "v1.24.3-gke.100".
+		majorStr, minorStr, _ := strings.Cut(strings.TrimPrefix(v.GitVersion, "v"), ".")
+		major, minor = versionNumber(majorStr), versionNumber(minorStr)
+	}
+	return major > 1 || (major == 1 && minor >= 21)
+}
+
+// versionNumber parses the leading digits of the version, e.g. 21 for "21+" and 24 for "24-gke.100".
+func versionNumber(s string) int {
+	end := 0
+	for end < len(s) && '0' <= s[end] && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
+
+func randStr(n int) (string, error) {
+	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	for _, v := range b {
+		builder.WriteByte(letters[int(v)%len(letters)])
+	}
+	return builder.String(), nil
+}
+
+// jobCreator edits the Job with the user's editor and applies it.
+type jobCreator struct {
+	clientset       kubernetes.Interface
+	kubectlPath     string
+	kubectlFlags    []string
+	kubectlEnv      []string
+	editorEnv       keyValueFlag
+	smartApply      bool
+	yes             bool
+	noEdit          bool
+	confirmTimeout  time.Duration
+	output          string
+	fieldManager    string
+	serverSide      bool
+	forceConflicts  bool
+	generateName    bool
+	noSuffix        bool
+	replace         bool
+	timeout         time.Duration
+	saveDir         string
+	tempDir         string
+	preApply        string
+	postApply       string
+	strictNamespace bool
+	diffFormat      string
+	editFormat      string
+	// pruneTemplate removes the empty fields from the manifest to edit.
+	pruneTemplate bool
+	// info is where the informational messages are written.
+	info io.Writer
+	tty  *tty.TTY
+
+	// editor, confirmer and applier replace the editor, the confirmation on the terminal
+	// and kubectl when they are set. They are used by tests which have no terminal.
+	editor    func(filename string) error
+	confirmer func() (bool, error)
+	applier   func(ctx context.Context, args []string) (stdout string, err error)
+}
+
+// needsTTY reports whether the terminal is used to create the Jobs.
+// pick is true when the CronJob is picked on the terminal.
+func (c *jobCreator) needsTTY(pick bool) bool {
+	if c.editor == nil && !c.noEdit {
+		return true
+	}
+	if c.confirmer == nil && !c.yes {
+		return true
+	}
+	return pick
+}
+
+// openInteractiveTTY opens the terminal used by the editor and the confirmation.
+func openInteractiveTTY(open func() (*tty.TTY, error)) (*tty.TTY, error) {
+	t, err := open()
+	if err != nil {
+		return nil, fmt.Errorf("no interactive terminal is available to edit the job: %w (use -no-edit with -yes to apply it without the terminal, or -output-dir to write the manifest)", err)
+	}
+	return t, nil
+}
+
+// createWithFileName returns the applied Job, or nil when the user canceled.
+func (c *jobCreator) createWithFileName(filename string, job *batchv1.Job) (*batchv1.Job, error) {
+	var f *os.File
+	var err error
+	if filename == "" {
+		ext := "yaml"
+		if c.editFormat == "json" {
+			ext = "json"
+		}
+		f, err = os.CreateTemp(c.tempDir, tempFilePattern(job, ext))
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(f.Name())
+		stop := cleanupOnSignal(f.Name(), os.Exit)
+		defer stop()
+	} else {
+		f, err = createPrivateFile(filename)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c.create(f, job)
+}
+
+// checkWritableDir checks that a file can be created in dir.
+func checkWritableDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	f, err := os.CreateTemp(dir, "kj.check.*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// tempFilePattern returns "kj.<namespace>.<name>.*.<ext>" so that the editor windows can be told apart.
+func tempFilePattern(job *batchv1.Job, ext string) string {
+	name := job.Name
+	if name == "" {
+		name = strings.TrimSuffix(job.GenerateName, "-")
+	}
+	return fmt.Sprintf("%s.%s.%s.*.%s", cmdName, sanitizeFileName(job.Namespace), sanitizeFileName(name), ext)
+}
+
+// sanitizeFileName replaces the characters which may be invalid in a file name with "_".
+// "*" is also replaced because os.CreateTemp replaces it with the random string.
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '-', r == '.', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// manifestFileMode keeps the manifest private because env vars in it may contain secrets.
+const manifestFileMode = 0o600
+
+// createPrivateFile creates or truncates filename, and makes it readable only by the user
+// even if it already exists with a wider mode.
+func createPrivateFile(filename string) (*os.File, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, manifestFileMode)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(manifestFileMode); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// cleanupOnSignal removes filename and exits when kj is terminated by SIGTERM.
+// SIGINT from the terminal is also delivered to the editor and kubectl running in the foreground,
+// so kj only keeps running on SIGINT and the file is removed when they exit and kj returns normally.
+func cleanupOnSignal(filename string, exit func(code int)) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				if sig == syscall.SIGTERM {
+					os.Remove(filename)
+					exit(exitStatusErr)
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
+
+func (c *jobCreator) create(f *os.File, job *batchv1.Job) (*batchv1.Job, error) {
+	marshal := jobToYaml
+	if c.editFormat == "json" {
+		marshal = jobToJSON
+	}
+	data, err := marshal(job, c.pruneTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = f.Write(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := c.edit(f.Name()); err != nil {
+		return nil, err
+	}
+
+	edited, err := readJobFile(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateJob(edited); err != nil {
+		return nil, fmt.Errorf("%s is invalid: %w", f.Name(), err)
+	}
+	if refreshJobNameLabel(edited) {
+		// kubectl applies the file, so the refreshed label is written back.
+		data, err := marshal(edited, false)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(f.Name(), data, 0o600); err != nil {
+			return nil, err
+		}
+	}
+	if edited.Namespace == "" {
+		// kubectl would apply the Job without namespace to the namespace of the kubeconfig context,
+		// so the namespace kj resolved is passed by --namespace.
+		edited.Namespace = job.Namespace
+	}
+
+	// The namespace is checked before the confirmation so that the user can cancel.
+	if err := checkNamespace(os.Stderr, edited.Namespace, job.Namespace, c.strictNamespace); err != nil {
+		return nil, err
+	}
+
+	if c.diffFormat != "" {
+		changes, err := diffJobs(job, edited)
+		if err != nil {
+			return nil, err
+		}
+		// The diff is informational and must not be mixed with the output of -o name.
+		if err := printDiff(c.info, c.diffFormat, changes); err != nil {
+			return nil, err
+		}
+	}
+
+	ask := c.confirmer
+	if ask == nil {
+		ask = c.confirmOnTTY
+	}
+	confirmed, err := c.confirm(ask)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		fmt.Fprintln(c.info, "canceled")
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	verb := "apply"
+	switch {
+	case c.generateName:
+		// kubectl apply doesn't support generateName.
+		verb = "create"
+	case c.replace:
+		verb = "create"
+	case c.noSuffix:
+		// The existing Job of the same name is reported by replaceExistingJob
+		// instead of being updated by kubectl apply.
+		verb = "create"
+	case c.smartApply:
+		verb, err = smartApplyVerb(ctx, c.clientset, edited)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if verb == "create" && edited.Name != "" {
+		if err := replaceExistingJob(ctx, c.clientset, edited, c.replace); err != nil {
+			return nil, err
+		}
+	}
+
+	err = c.applyWithHooks(edited, func() error {
+		apply := c.applier
+		if apply == nil {
+			apply = c.runKubectl
+		}
+		stdout, err := apply(ctx, c.kubectlArgs(verb, f.Name(), edited.Namespace))
+		if err != nil {
+			return err
+		}
+
+		c.printApplied(os.Stdout, stdout)
+		if c.generateName {
+			edited.Name = resourceName(stdout)
+		}
+
+		if c.saveDir != "" {
+			path, err := saveManifest(c.saveDir, f.Name(), edited.Namespace, edited.Name, time.Now())
+			if err != nil {
+				return fmt.Errorf("job.batch/%s is applied, but %w", edited.Name, err)
+			}
+			fmt.Fprintf(c.info, "saved the manifest to %s\n", path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return edited, nil
+}
+
+// confirmOnTTY asks the user whether to apply the Job on the terminal.
+func (c *jobCreator) confirmOnTTY() (bool, error) {
+	prompt := "Do you want to create a job with the change you just made?"
+	if c.noEdit {
+		prompt = "Do you want to create the job?"
+	}
+	// The reader stops waiting for the input after the confirmation. Otherwise it takes
+	// the keys meant for the next reader of the terminal, e.g. the editor of the next Job.
+	stop := make(chan struct{})
+	defer close(stop)
+	in := c.tty.Input()
+	readLine := func() (string, error) {
+		if err := waitInput(in, stop); err != nil {
+			return "", err
+		}
+		return ttyutil.ReadLine(c.tty)
+	}
+	return confirmByUser(readLine, c.tty.Output(), confirmOptions{
+		prompt:  prompt,
+		timeout: c.confirmTimeout,
+	})
+}
+
+// runKubectl runs kubectl with args and returns its stdout.
+func (c *jobCreator) runKubectl(ctx context.Context, args []string) (string, error) {
+	// kubectl may ask for the credentials. Without the terminal, e.g. -no-edit -yes in CI,
+	// the standard streams are used instead.
+	if c.tty == nil {
+		return execKubectl(ctx, c.kubectlPath, args, c.kubectlEnv, os.Stdin, os.Stderr)
+	}
+	return execKubectl(ctx, c.kubectlPath, args, c.kubectlEnv, c.tty.Input(), c.tty.Output())
+}
+
+// execKubectl runs the kubectl at path. The stderr is streamed to stderr and also
+// included in the returned error so that the cause is not only "exit status 1".
+func execKubectl(ctx context.Context, path string, args, env []string, stdin io.Reader, stderr io.Writer) (string, error) {
+	// kubectl output is written to stdout so that scripts can capture the created Job.
+	var stdout, errOut bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = io.MultiWriter(stderr, &errOut)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(errOut.String()); msg != "" {
+			return "", fmt.Errorf("kubectl %s failed: %s: %w", args[0], msg, err)
+		}
+		return "", fmt.Errorf("kubectl %s failed: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// printApplied prints the kubectl output of the applied Job.
+func (c *jobCreator) printApplied(w io.Writer, kubectlOutput string) {
+	switch {
+	case c.output == "name":
+		fmt.Fprintln(w, resourceName(kubectlOutput))
+	case c.generateName:
+		fmt.Fprintf(w, "job.batch/%s created\n", resourceName(kubectlOutput))
+	default:
+		fmt.Fprint(w, kubectlOutput)
+	}
+}
+
+// setQuiet makes kj print only the name of the created Job and the errors.
+func (c *jobCreator) setQuiet() {
+	c.output = "name"
+	c.info = io.Discard
+}
+
+// applyWithHooks runs apply between the -pre-apply and -post-apply hooks.
+// A failing pre-apply hook aborts applying, but a failing post-apply hook is only warned
+// because the Job is already applied.
+func (c *jobCreator) applyWithHooks(job *batchv1.Job, apply func() error) error {
+	if c.preApply != "" {
+		if err := runHook(c.preApply, job); err != nil {
+			return fmt.Errorf("pre-apply hook failed: %w", err)
+		}
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+	if c.postApply != "" {
+		if err := runHook(c.postApply, job); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: warning: post-apply hook failed: %v\n", cmdName, err)
+		}
+	}
+	return nil
+}
+
+// edit opens filename with the editor on the terminal unless -no-edit is specified.
+func (c *jobCreator) edit(filename string) error {
+	if c.noEdit {
+		return nil
+	}
+	if c.editor != nil {
+		return c.editor(filename)
+	}
+
+	editorWithArgs := append(editorCommand(os.Getenv), filename)
+	cmd := exec.Command(editorWithArgs[0], editorWithArgs[1:]...)
+	cmd.Stdin = c.tty.Input()
+	cmd.Stdout = c.tty.Output()
+	cmd.Stderr = c.tty.Output()
+	if len(c.editorEnv) > 0 {
+		cmd.Env = append(os.Environ(), c.editorEnv...)
+	}
+	return cmd.Run()
+}
+
+func (c *jobCreator) kubectlArgs(verb, filename, namespace string) []string {
+	args := []string{verb, "-f", filename}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+	args = append(args, c.kubectlFlags...)
+	if c.fieldManager != "" {
+		args = append(args, "--field-manager", c.fieldManager)
+	}
+	if c.serverSide {
+		// kubectl reports the conflicting fields and their managers when the apply conflicts.
+		args = append(args, "--server-side")
+		if c.forceConflicts {
+			args = append(args, "--force-conflicts")
+		}
+	}
+	// The server-assigned name is needed with generateName.
+	if c.output == "name" || c.generateName {
+		args = append(args, "-o", "name")
+	}
+	return args
+}
+
+// resourceName returns "name" from the kubectl output "job.batch/name".
+func resourceName(kubectlOutput string) string {
+	s := strings.TrimSpace(kubectlOutput)
+	if _, name, ok := strings.Cut(s, "/"); ok {
+		return name
+	}
+	return s
+}
+
+// lookKubectl returns the absolute path of kubectl, or an error which explains how to fix it.
+func lookKubectl(kubectl string, lookPath func(string) (string, error)) (string, error) {
+	path, err := lookPath(kubectl)
+	if err != nil {
+		return "", fmt.Errorf("%s is not found: kj applies the Job with kubectl. Install kubectl (https://kubernetes.io/docs/tasks/tools/) or specify it with -kubectl-path (%w)", kubectl, err)
+	}
+	return path, nil
+}
+
+// confirm asks the user by ask unless -yes is specified.
+func (c *jobCreator) confirm(ask func() (bool, error)) (bool, error) {
+	if c.yes {
+		return true, nil
+	}
+	return ask()
+}
+
+// editorCommand returns the editor with its arguments in the same priority as kubectl:
+// KUBE_EDITOR, EDITOR and then vi.
+func editorCommand(getenv func(string) string) []string {
+	for _, env := range []string{"KUBE_EDITOR", "EDITOR"} {
+		if editor := strings.Fields(getenv(env)); len(editor) > 0 {
+			return editor
+		}
+	}
+	return []string{"vi"}
+}
+
+// smartApplyVerb returns "create" when the Job doesn't exist, and "apply" when it does.
+func smartApplyVerb(ctx context.Context, clientset kubernetes.Interface, job *batchv1.Job) (string, error) {
+	_, err := clientset.BatchV1().Jobs(job.Namespace).Get(ctx, job.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "create", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to check whether job %s exists: %w", job.Name, err)
+	}
+	return "apply", nil
+}
+
+// replaceExistingJob makes sure that the Job can be created.
+// When the Job exists, it is deleted if replace is true, otherwise an AlreadyExists error is returned.
+func replaceExistingJob(ctx context.Context, clientset kubernetes.Interface, job *batchv1.Job, replace bool) error {
+	jobs := clientset.BatchV1().Jobs(job.Namespace)
+	_, err := jobs.Get(ctx, job.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check whether job %s exists: %w", job.Name, err)
+	}
+
+	if !replace {
+		err := apierrors.NewAlreadyExists(batchv1.Resource("jobs"), job.Name)
+		return fmt.Errorf("%w. Use -replace to delete and recreate it", err)
+	}
+
+	err = jobs.Delete(ctx, job.Name, metav1.DeleteOptions{PropagationPolicy: toPtr(metav1.DeletePropagationBackground)})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete job %s: %w", job.Name, err)
+	}
+
+	// The Job remains until its finalizers are processed.
+	return wait.PollUntilContextCancel(ctx, 500*time.Millisecond, true, func(ctx context.Context) (bool, error) {
+		_, err := jobs.Get(ctx, job.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+// readJobFile reads the edited manifest. JSON is also accepted because it is a subset of YAML.
+func readJobFile(filename string) (*batchv1.Job, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var job batchv1.Job
+	if err := yaml.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return &job, nil
+}
+
+// checkNamespace warns when the namespace of the edited Job differs from the target namespace
+// because kubectl applies the Job to the namespace in the file. It is an error with strict.
+func checkNamespace(w io.Writer, edited, target string, strict bool) error {
+	if edited == target {
+		return nil
+	}
+	if strict {
+		return fmt.Errorf("namespace is changed from %q to %q in the editor", target, edited)
+	}
+	fmt.Fprintf(w, "%s: warning: the job will be applied to namespace %q instead of %q\n", cmdName, edited, target)
+	return nil
+}
+
+// validateJob checks the edited manifest is still a Job which can run,
+// so that a broken edit is reported before kubectl is called.
+func validateJob(job *batchv1.Job) error {
+	if job.Kind != "Job" {
+		return fmt.Errorf(`kind must be "Job", got %q`, job.Kind)
+	}
+	if len(job.Spec.Template.Spec.Containers) == 0 {
+		return errors.New("spec.template.spec.containers must have at least one container")
+	}
+	podSpec := job.Spec.Template.Spec
+	for _, c := range append(podSpec.InitContainers, podSpec.Containers...) {
+		if strings.TrimSpace(c.Image) == "" {
+			return fmt.Errorf("the image of container %q is empty", c.Name)
+		}
+	}
+	return nil
+}
+
+// jobToYaml returns the manifest to edit. The keys are sorted alphabetically at every level,
+// so the top-level keys are always apiVersion, kind, metadata and spec, and the output is
+// stable between runs.
+func jobToYaml(job *batchv1.Job, prune bool) ([]byte, error) {
+	// Marshal with ownerReferences commented out. The Job created from a Job or in
+	// another namespace has no owner, so there is nothing to comment out.
+	var ownerRefs []byte
+	if len(job.ObjectMeta.OwnerReferences) > 0 {
+		var err error
+		ownerRefs, err = yaml.Marshal(map[string]any{"ownerReferences": job.ObjectMeta.OwnerReferences})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	job.ObjectMeta.OwnerReferences = nil
+	obj, err := toObject(job)
+	if err != nil {
+		return nil, err
+	}
+	removeEmptyServerFields(obj)
+	if prune {
+		pruneEmptyFields(obj)
+	}
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	if len(ownerRefs) == 0 {
+		return data, nil
+	}
+
+	commentForOwnerRefs := "  # "
+	commentedOwnerRefs := commentForOwnerRefs + strings.ReplaceAll(string(ownerRefs), "\n", "\n"+commentForOwnerRefs)
+	commentedOwnerRefs = strings.TrimSuffix(commentedOwnerRefs, commentForOwnerRefs)
+
+	data = slices.Insert(data, metadataKeyIndex(data, "ownerReferences"), []byte(commentedOwnerRefs)...)
+	return data, nil
+}
+
+// jobToJSON returns the manifest to edit in JSON. JSON has no comments,
+// so ownerReferences is dropped, which is the same as the commented out one in YAML.
+func jobToJSON(job *batchv1.Job, prune bool) ([]byte, error) {
+	job.ObjectMeta.OwnerReferences = nil
+	obj, err := toObject(job)
+	if err != nil {
+		return nil, err
+	}
+	removeEmptyServerFields(obj)
+	if prune {
+		pruneEmptyFields(obj)
+	}
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// metadataKeyIndex returns the offset in data where key would be placed in the metadata
+// in alphabetical order, which is the order sigs.k8s.io/yaml writes the keys.
+func metadataKeyIndex(data []byte, key string) int {
+	offset := 0
+	inMetadata := false
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		switch {
+		case line == "metadata:\n":
+			inMetadata = true
+		case inMetadata && !strings.HasPrefix(line, " "):
+			// The end of the metadata.
+			return offset
+		case inMetadata && len(line) > 2 && line[2] != ' ' && line[2] != '-':
+			if k, _, _ := strings.Cut(line[2:], ":"); k > key {
+				return offset
+			}
+		}
+		offset += len(line)
+	}
+	return len(data)
+}
+
+// stringsFlag is a repeatable flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// keyValueFlag is a repeatable flag which accepts KEY=VALUE.
+type keyValueFlag []string
+
+func (f *keyValueFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *keyValueFlag) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("%q should be KEY=VALUE format", value)
+	}
+	*f = append(*f, value)
+	return nil
+}
+
+func toObject(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// UseNumber keeps integers like activeDeadlineSeconds from becoming float64.
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var obj map[string]any
+	if err := d.Decode(&obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// removeEmptyServerFields removes "status: {}" and "creationTimestamp: null", which are meaningless for creation.
+func removeEmptyServerFields(obj map[string]any) {
+	delete(obj, "status")
+	removeNullCreationTimestamp(obj)
+}
+
+// keptEmptyFields are meaningful even when empty, e.g. "emptyDir: {}" selects the volume type
+// and "labelSelector: {}" matches all pods.
+var keptEmptyFields = map[string]bool{
+	"emptyDir":          true,
+	"downwardAPI":       true,
+	"labelSelector":     true,
+	"namespaceSelector": true,
+	"selector":          true,
+}
+
+// pruneEmptyFields removes null, {} and [] recursively for -prune-template.
+// The zero numbers and the false booleans are kept because they may differ from the defaults.
+func pruneEmptyFields(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			pruneEmptyFields(child)
+			if !keptEmptyFields[k] && isEmptyValue(child) {
+				delete(v, k)
+			}
+		}
+	case []any:
+		for _, child := range v {
+			pruneEmptyFields(child)
+		}
+	}
+}
+
+func isEmptyValue(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case map[string]any:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
+	}
+	return false
+}
+
+func removeNullCreationTimestamp(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		if ts, ok := v["creationTimestamp"]; ok && ts == nil {
+			delete(v, "creationTimestamp")
+		}
+		for _, child := range v {
+			removeNullCreationTimestamp(child)
+		}
+	case []any:
+		for _, child := range v {
+			removeNullCreationTimestamp(child)
+		}
+	}
 }
 
 func toPtr[T any](t T) *T {
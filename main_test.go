@@ -1,12 +1,39 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/mattn/go-tty"
 	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestGetNamespaceAndName(t *testing.T) {
@@ -96,6 +123,40 @@ func TestIsCronJobGA(t *testing.T) {
 			},
 			expect: true,
 		},
+		"1.9": {
+			input: &version.Info{
+				Major: "1",
+				Minor: "9",
+			},
+			expect: false,
+		},
+		"1.24-gke.100": {
+			input: &version.Info{
+				Major: "1",
+				Minor: "24-gke.100",
+			},
+			expect: true,
+		},
+		"1.100": {
+			input: &version.Info{
+				Major: "1",
+				Minor: "100",
+			},
+			expect: true,
+		},
+		"2.0": {
+			input: &version.Info{
+				Major: "2",
+				Minor: "0",
+			},
+			expect: true,
+		},
+		"only gitVersion": {
+			input: &version.Info{
+				GitVersion: "v1.24.3-gke.100",
+			},
+			expect: true,
+		},
 	}
 
 	for n, tt := range tests {
@@ -136,7 +197,6 @@ func TestJobToYaml(t *testing.T) {
 			expect: []byte(`apiVersion: batch/v1
 kind: Job
 metadata:
-  creationTimestamp: null
   name: test
   namespace: default
   # ownerReferences:
@@ -147,18 +207,47 @@ metadata:
   #   uid: ""
 spec:
   template:
-    metadata:
-      creationTimestamp: null
+    metadata: {}
+    spec:
+      containers: null
+`),
+		},
+		"ownerReferences is placed by the key order": {
+			job: &batchv1.Job{
+				TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "test",
+					Namespace:       "default",
+					Annotations:     map[string]string{"example.com/namespace": "other"},
+					ResourceVersion: "1",
+					OwnerReferences: []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "CronJob", Name: "test"}},
+				},
+			},
+			expect: []byte(`apiVersion: batch/v1
+kind: Job
+metadata:
+  annotations:
+    example.com/namespace: other
+  name: test
+  namespace: default
+  # ownerReferences:
+  # - apiVersion: batch/v1
+  #   kind: CronJob
+  #   name: test
+  #   uid: ""
+  resourceVersion: "1"
+spec:
+  template:
+    metadata: {}
     spec:
       containers: null
-status: {}
 `),
 		},
 	}
 
 	for n, tt := range tests {
 		t.Run(n, func(t *testing.T) {
-			got, err := jobToYaml(tt.job)
+			got, err := jobToYaml(tt.job, false)
 			if err != nil {
 				t.Fatalf("jobToYaml got error: %v", err)
 			}
@@ -168,3 +257,2057 @@ status: {}
 		})
 	}
 }
+
+func TestJobToYaml_Prune(t *testing.T) {
+	job := &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: toPtr(int32(0)),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Image: "app:v1", VolumeMounts: []corev1.VolumeMount{{Name: "tmp", MountPath: "/tmp"}}},
+					},
+					SecurityContext: &corev1.PodSecurityContext{},
+					Volumes: []corev1.Volume{
+						{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+					},
+				},
+			},
+		},
+	}
+
+	data, err := jobToYaml(job, true)
+	if err != nil {
+		t.Fatalf("jobToYaml got error: %v", err)
+	}
+
+	expect := `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: test
+  namespace: default
+spec:
+  backoffLimit: 0
+  template:
+    spec:
+      containers:
+      - image: app:v1
+        name: app
+        volumeMounts:
+        - mountPath: /tmp
+          name: tmp
+      volumes:
+      - emptyDir: {}
+        name: tmp
+`
+	if diff := cmp.Diff(expect, string(data)); diff != "" {
+		t.Errorf("jobToYaml result diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestPruneEmptyFields(t *testing.T) {
+	tests := map[string]struct {
+		obj    map[string]any
+		expect map[string]any
+	}{
+		"noise": {
+			obj: map[string]any{
+				"metadata":        map[string]any{},
+				"resources":       map[string]any{},
+				"securityContext": map[string]any{"capabilities": map[string]any{}},
+				"status":          map[string]any{},
+				"env":             []any{},
+				"image":           "app:v1",
+			},
+			expect: map[string]any{"image": "app:v1"},
+		},
+		"volume types": {
+			obj: map[string]any{
+				"emptyDir":    map[string]any{},
+				"downwardAPI": map[string]any{},
+			},
+			expect: map[string]any{
+				"emptyDir":    map[string]any{},
+				"downwardAPI": map[string]any{},
+			},
+		},
+		"selectors matching everything": {
+			obj: map[string]any{
+				"podAffinityTerm": map[string]any{
+					"labelSelector":     map[string]any{},
+					"namespaceSelector": map[string]any{},
+					"topologyKey":       "kubernetes.io/hostname",
+				},
+				"selector": map[string]any{},
+			},
+			expect: map[string]any{
+				"podAffinityTerm": map[string]any{
+					"labelSelector":     map[string]any{},
+					"namespaceSelector": map[string]any{},
+					"topologyKey":       "kubernetes.io/hostname",
+				},
+				"selector": map[string]any{},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			pruneEmptyFields(tt.obj)
+			if diff := cmp.Diff(tt.expect, tt.obj); diff != "" {
+				t.Errorf("pruneEmptyFields result diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateJob(t *testing.T) {
+	tests := map[string]struct {
+		manifest   string
+		wantErr    bool
+		errMessage string
+	}{
+		"valid job": {
+			manifest: `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: test
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+`,
+		},
+		"containers are removed": {
+			manifest: `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: test
+spec:
+  template:
+    spec:
+      restartPolicy: Never
+`,
+			wantErr: true,
+		},
+		"image of the second container is empty": {
+			manifest: `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: test
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+      - name: sidecar
+        image: ""
+`,
+			wantErr:    true,
+			errMessage: `the image of container "sidecar" is empty`,
+		},
+		"image of the init container is removed": {
+			manifest: `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: test
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+      containers:
+      - name: app
+        image: app:v1
+`,
+			wantErr:    true,
+			errMessage: `the image of container "migrate" is empty`,
+		},
+		"kind is removed": {
+			manifest: `apiVersion: batch/v1
+metadata:
+  name: test
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+`,
+			wantErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "job.yaml")
+			if err := os.WriteFile(filename, []byte(tt.manifest), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			job, err := readJobFile(filename)
+			if err != nil {
+				t.Fatalf("readJobFile got error: %v", err)
+			}
+
+			err = validateJob(job)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateJob expected error %t, got %v", tt.wantErr, err)
+			}
+			if tt.errMessage != "" && err != nil && err.Error() != tt.errMessage {
+				t.Errorf("validateJob error expected %q, got %q", tt.errMessage, err.Error())
+			}
+		})
+	}
+}
+
+func TestKeyValueFlag(t *testing.T) {
+	var f keyValueFlag
+	if err := f.Set("FOO=bar"); err != nil {
+		t.Fatalf("Set got error: %v", err)
+	}
+	if err := f.Set("BAZ="); err != nil {
+		t.Fatalf("Set got error: %v", err)
+	}
+	if err := f.Set("INVALID"); err == nil {
+		t.Errorf("Set should return error for value without '='")
+	}
+
+	expect := keyValueFlag{"FOO=bar", "BAZ="}
+	if diff := cmp.Diff(expect, f); diff != "" {
+		t.Errorf("keyValueFlag diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestSmartApplyVerb(t *testing.T) {
+	existing := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "existing"},
+	}
+	clientset := fake.NewSimpleClientset(existing)
+
+	tests := map[string]struct {
+		name   string
+		expect string
+	}{
+		"new job is created": {
+			name:   "new",
+			expect: "create",
+		},
+		"existing job is applied": {
+			name:   "existing",
+			expect: "apply",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: tt.name}}
+			got, err := smartApplyVerb(context.Background(), clientset, job)
+			if err != nil {
+				t.Fatalf("smartApplyVerb got error: %v", err)
+			}
+			if got != tt.expect {
+				t.Errorf(`smartApplyVerb expected "%s", got "%s"`, tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestNewRestConfig_Impersonate(t *testing.T) {
+	o := clientOptions{
+		kubeconfig:         "testdata/kubeconfig",
+		explicitKubeconfig: true,
+		impersonate: rest.ImpersonationConfig{
+			UserName: "alice",
+			Groups:   []string{"dev", "ops"},
+		},
+	}
+
+	config, err := newRestConfig(o)
+	if err != nil {
+		t.Fatalf("newRestConfig got error: %v", err)
+	}
+	if diff := cmp.Diff(o.impersonate, config.Impersonate); diff != "" {
+		t.Errorf("Impersonate diff (-expect, +got)\n%s", diff)
+	}
+
+	expectFlags := []string{"--kubeconfig", "testdata/kubeconfig", "--as", "alice", "--as-group", "dev", "--as-group", "ops"}
+	if diff := cmp.Diff(expectFlags, o.kubectlFlags()); diff != "" {
+		t.Errorf("kubectlFlags diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestNewRestConfig_ClusterAndUser(t *testing.T) {
+	o := clientOptions{
+		kubeconfig:         "testdata/kubeconfig",
+		explicitKubeconfig: true,
+		// The current context is b.
+		cluster: "A",
+		user:    "a",
+	}
+
+	config, err := newRestConfig(o)
+	if err != nil {
+		t.Fatalf("newRestConfig got error: %v", err)
+	}
+	if config.Host != "https://a.example.com" {
+		t.Errorf(`host expected "https://a.example.com", got "%s"`, config.Host)
+	}
+	if config.BearerToken != "token-a" {
+		t.Errorf(`bearer token expected "token-a", got "%s"`, config.BearerToken)
+	}
+
+	expectFlags := []string{"--kubeconfig", "testdata/kubeconfig", "--cluster", "A", "--user", "a"}
+	if diff := cmp.Diff(expectFlags, o.kubectlFlags()); diff != "" {
+		t.Errorf("kubectlFlags diff (-expect, +got)\n%s", diff)
+	}
+
+	// kubectl reads the list in $KUBECONFIG by itself.
+	o.kubeconfig = strings.Join([]string{"testdata/kubeconfig", "testdata/other"}, string(filepath.ListSeparator))
+	if diff := cmp.Diff([]string{"--cluster", "A", "--user", "a"}, o.kubectlFlags()); diff != "" {
+		t.Errorf("kubectlFlags diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestNewRestConfig_Insecure(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	data := `apiVersion: v1
+clusters:
+- cluster:
+    server: https://dev.example.com
+    certificate-authority-data: ZHVtbXk=
+  name: dev
+contexts:
+- context:
+    cluster: dev
+    user: dev
+  name: dev
+current-context: dev
+users:
+- name: dev
+  user:
+    token: token
+`
+	if err := os.WriteFile(kubeconfig, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	o := clientOptions{kubeconfig: kubeconfig, explicitKubeconfig: true, insecure: true}
+	config, err := newRestConfig(o)
+	if err != nil {
+		t.Fatalf("newRestConfig got error: %v", err)
+	}
+	if !config.TLSClientConfig.Insecure {
+		t.Error("TLSClientConfig.Insecure expected true")
+	}
+	if len(config.TLSClientConfig.CAData) != 0 || config.TLSClientConfig.CAFile != "" {
+		t.Errorf("CA expected to be cleared, got CAData=%q CAFile=%q", config.TLSClientConfig.CAData, config.TLSClientConfig.CAFile)
+	}
+	if diff := cmp.Diff([]string{"--kubeconfig", kubeconfig, "--insecure-skip-tls-verify"}, o.kubectlFlags()); diff != "" {
+		t.Errorf("kubectlFlags diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestNewRestConfig_CAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caFile, []byte("dummy"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	o := clientOptions{kubeconfig: "testdata/kubeconfig", explicitKubeconfig: true, caFile: caFile}
+	config, err := newRestConfig(o)
+	if err != nil {
+		t.Fatalf("newRestConfig got error: %v", err)
+	}
+	if config.TLSClientConfig.CAFile != caFile {
+		t.Errorf(`CAFile expected "%s", got "%s"`, caFile, config.TLSClientConfig.CAFile)
+	}
+	if diff := cmp.Diff([]string{"--kubeconfig", "testdata/kubeconfig", "--certificate-authority", caFile}, o.kubectlFlags()); diff != "" {
+		t.Errorf("kubectlFlags diff (-expect, +got)\n%s", diff)
+	}
+
+	o.caFile = filepath.Join(t.TempDir(), "missing.crt")
+	if _, err := newRestConfig(o); err == nil {
+		t.Error("newRestConfig should return error when -ca-file doesn't exist")
+	}
+}
+
+func TestNewRestConfig_ProxyURL(t *testing.T) {
+	o := clientOptions{kubeconfig: "testdata/kubeconfig", explicitKubeconfig: true, proxyURL: "http://proxy.example.com:3128"}
+	config, err := newRestConfig(o)
+	if err != nil {
+		t.Fatalf("newRestConfig got error: %v", err)
+	}
+	if config.Proxy == nil {
+		t.Fatal("Proxy should be set")
+	}
+	req, err := http.NewRequest(http.MethodGet, config.Host, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy, err := config.Proxy(req)
+	if err != nil {
+		t.Fatalf("Proxy got error: %v", err)
+	}
+	if proxy.String() != o.proxyURL {
+		t.Errorf(`proxy expected "%s", got "%s"`, o.proxyURL, proxy)
+	}
+	expectEnv := []string{"HTTPS_PROXY=" + o.proxyURL, "HTTP_PROXY=" + o.proxyURL}
+	if diff := cmp.Diff(expectEnv, o.kubectlEnv()); diff != "" {
+		t.Errorf("kubectlEnv diff (-expect, +got)\n%s", diff)
+	}
+
+	for _, invalid := range []string{"proxy.example.com:3128", "ftp://proxy.example.com", "http://", "http://[::1"} {
+		o.proxyURL = invalid
+		if _, err := newRestConfig(o); err == nil {
+			t.Errorf("newRestConfig should return error for -proxy-url %q", invalid)
+		}
+	}
+}
+
+func TestNewRestConfig_ClusterAndUserNotFound(t *testing.T) {
+	tests := map[string]struct {
+		cluster string
+		user    string
+		expect  string
+	}{
+		"cluster": {
+			cluster: "C",
+			expect:  `cluster "C" is not found in kubeconfig (available: A, B)`,
+		},
+		"user": {
+			user:   "c",
+			expect: `user "c" is not found in kubeconfig (available: a, b)`,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			_, err := newRestConfig(clientOptions{
+				kubeconfig:         "testdata/kubeconfig",
+				explicitKubeconfig: true,
+				cluster:            tt.cluster,
+				user:               tt.user,
+			})
+			if err == nil {
+				t.Fatal("newRestConfig should return error")
+			}
+			if err.Error() != tt.expect {
+				t.Errorf(`error expected "%s", got "%s"`, tt.expect, err.Error())
+			}
+		})
+	}
+}
+
+func TestJobCreator_Confirm(t *testing.T) {
+	t.Run("-yes skips asking", func(t *testing.T) {
+		c := &jobCreator{yes: true}
+		confirmed, err := c.confirm(func() (bool, error) {
+			t.Fatal("ask should not be called when -yes is specified")
+			return false, nil
+		})
+		if err != nil {
+			t.Fatalf("confirm got error: %v", err)
+		}
+		if !confirmed {
+			t.Errorf("confirm expected true")
+		}
+	})
+
+	t.Run("without -yes asks the user", func(t *testing.T) {
+		c := &jobCreator{}
+		var asked bool
+		confirmed, err := c.confirm(func() (bool, error) {
+			asked = true
+			return false, nil
+		})
+		if err != nil {
+			t.Fatalf("confirm got error: %v", err)
+		}
+		if !asked || confirmed {
+			t.Errorf("confirm expected to ask and return false, got asked=%t confirmed=%t", asked, confirmed)
+		}
+	})
+}
+
+func TestJobCreator_Edit_NoEdit(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "edited")
+	editor := filepath.Join(dir, "editor.sh")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\ntouch "+marker+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBE_EDITOR", editor)
+
+	c := &jobCreator{noEdit: true}
+	if err := c.edit(filepath.Join(dir, "job.yaml")); err != nil {
+		t.Fatalf("edit got error: %v", err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Errorf("editor should not be invoked with -no-edit")
+	}
+}
+
+func TestExecKubectl(t *testing.T) {
+	kubectl := filepath.Join(t.TempDir(), "kubectl")
+	body := `#!/bin/sh
+if [ "$1" = "create" ]; then
+  echo 'Error from server (Forbidden): jobs.batch is forbidden' >&2
+  exit 1
+fi
+echo "job.batch/test created"
+`
+	if err := os.WriteFile(kubectl, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var stderr bytes.Buffer
+	stdout, err := execKubectl(context.Background(), kubectl, []string{"apply", "-f", "job.yaml"}, nil, nil, &stderr)
+	if err != nil {
+		t.Fatalf("execKubectl got error: %v", err)
+	}
+	if stdout != "job.batch/test created\n" {
+		t.Errorf("unexpected stdout %q", stdout)
+	}
+
+	_, err = execKubectl(context.Background(), kubectl, []string{"create", "-f", "job.yaml"}, nil, nil, &stderr)
+	if err == nil {
+		t.Fatal("execKubectl should return error when kubectl fails")
+	}
+	expect := "kubectl create failed: Error from server (Forbidden): jobs.batch is forbidden: exit status 1"
+	if err.Error() != expect {
+		t.Errorf(`execKubectl error expected "%s", got "%s"`, expect, err.Error())
+	}
+	if stderr.String() != "Error from server (Forbidden): jobs.batch is forbidden\n" {
+		t.Errorf("stderr should be streamed, got %q", stderr.String())
+	}
+}
+
+func TestOpenInteractiveTTY(t *testing.T) {
+	_, err := openInteractiveTTY(func() (*tty.TTY, error) {
+		return nil, errors.New("open /dev/tty: no such device or address")
+	})
+	if err == nil {
+		t.Fatal("openInteractiveTTY should return error when tty can't be opened")
+	}
+
+	expect := "no interactive terminal is available to edit the job: open /dev/tty: no such device or address (use -no-edit with -yes to apply it without the terminal, or -output-dir to write the manifest)"
+	if err.Error() != expect {
+		t.Errorf(`openInteractiveTTY error expected "%s", got "%s"`, expect, err.Error())
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	tests := map[string]struct {
+		env    map[string]string
+		expect []string
+	}{
+		"KUBE_EDITOR is preferred": {
+			env:    map[string]string{"KUBE_EDITOR": "code --wait", "EDITOR": "nvim"},
+			expect: []string{"code", "--wait"},
+		},
+		"EDITOR is used without KUBE_EDITOR": {
+			env:    map[string]string{"EDITOR": "nvim"},
+			expect: []string{"nvim"},
+		},
+		"vi is the fallback": {
+			env:    map[string]string{},
+			expect: []string{"vi"},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got := editorCommand(func(key string) string { return tt.env[key] })
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Errorf("editorCommand diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		expect string
+	}{
+		"kubectl -o name output": {
+			input:  "job.batch/test-abc123\n",
+			expect: "test-abc123",
+		},
+		"name only": {
+			input:  "test-abc123",
+			expect: "test-abc123",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got := resourceName(tt.input)
+			if got != tt.expect {
+				t.Errorf(`resourceName expected "%s", got "%s"`, tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestJobCreator_KubectlArgs(t *testing.T) {
+	tests := map[string]struct {
+		creator   *jobCreator
+		namespace string
+		expect    []string
+	}{
+		"namespace is passed": {
+			creator:   &jobCreator{},
+			namespace: "batch",
+			expect:    []string{"apply", "-f", "job.yaml", "--namespace", "batch"},
+		},
+		"field manager is passed": {
+			creator: &jobCreator{fieldManager: "kj"},
+			expect:  []string{"apply", "-f", "job.yaml", "--field-manager", "kj"},
+		},
+		"generateName needs the server-assigned name": {
+			creator: &jobCreator{generateName: true},
+			expect:  []string{"create", "-f", "job.yaml", "-o", "name"},
+		},
+		"all options": {
+			creator: &jobCreator{
+				kubectlFlags: []string{"--as", "alice"},
+				fieldManager: "ci",
+				serverSide:   true,
+				output:       "name",
+			},
+			expect: []string{"apply", "-f", "job.yaml", "--as", "alice", "--field-manager", "ci", "--server-side", "-o", "name"},
+		},
+		"conflicts are surfaced by default": {
+			creator: &jobCreator{serverSide: true},
+			expect:  []string{"apply", "-f", "job.yaml", "--server-side"},
+		},
+		"force conflicts": {
+			creator: &jobCreator{serverSide: true, forceConflicts: true},
+			expect:  []string{"apply", "-f", "job.yaml", "--server-side", "--force-conflicts"},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			verb := "apply"
+			if tt.creator.generateName {
+				verb = "create"
+			}
+			got := tt.creator.kubectlArgs(verb, "job.yaml", tt.namespace)
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Errorf("kubectlArgs diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewJobTemplate_CanceledContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/version" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"major": "1", "minor": "29"}`)
+			return
+		}
+		// hang until the client gives up
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: srv.URL})
+	if err != nil {
+		t.Fatalf("failed to create clientset: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = findCronJob(ctx, clientset, true, "default", "test")
+	if err == nil {
+		t.Fatal("findCronJob should return error when the context is canceled")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("findCronJob should return promptly after cancel, took %s", elapsed)
+	}
+}
+
+// newJobFromCluster builds the Job from the CronJob name in the default namespace.
+func newJobFromCluster(clientset *fake.Clientset, name string, opts jobOptions) (*batchv1.Job, error) {
+	cj, err := findCronJob(context.Background(), clientset, true, "default", name)
+	if err != nil {
+		return nil, err
+	}
+	return newJob(cj, opts)
+}
+
+// newFakeClientset returns the fake clientset which reports the server version major.minor.
+func newFakeClientset(major, minor string, objects ...runtime.Object) *fake.Clientset {
+	clientset := fake.NewSimpleClientset(objects...)
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{Major: major, Minor: minor}
+	return clientset
+}
+
+func TestGetCronJob_BetaNotServed(t *testing.T) {
+	cj := &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"}}
+	clientset := newFakeClientset("1", "20", cj)
+	// The server answers 404 without the object name when the API is not served.
+	clientset.PrependReactor("*", "cronjobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetResource().Version != "v1beta1" {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewNotFound(schema.GroupResource{}, "")
+	})
+
+	got, err := getCronJob(context.Background(), clientset, false, "default", "test")
+	if err != nil {
+		t.Fatalf("getCronJob got error: %v", err)
+	}
+	if got.APIVersion != "batch/v1" {
+		t.Errorf(`apiVersion expected "batch/v1", got "%s"`, got.APIVersion)
+	}
+
+	cronJobs, err := listCronJobs(context.Background(), clientset, false, "default", "")
+	if err != nil {
+		t.Fatalf("listCronJobs got error: %v", err)
+	}
+	if len(cronJobs) != 1 {
+		t.Errorf("listCronJobs expected 1 CronJob, got %d", len(cronJobs))
+	}
+
+	if _, err := getCronJob(context.Background(), clientset, true, "default", "missing"); !apierrors.IsNotFound(err) {
+		t.Errorf("getCronJob expected NotFound for a missing CronJob, got %v", err)
+	}
+}
+
+func TestNewJob(t *testing.T) {
+	jobSpec := batchv1.JobSpec{
+		BackoffLimit: toPtr(int32(3)),
+		Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				Containers:    []corev1.Container{{Name: "app", Image: "app:v1"}},
+				RestartPolicy: corev1.RestartPolicyNever,
+			},
+		},
+	}
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "uid"},
+		Spec: batchv1.CronJobSpec{
+			Schedule:    "0 * * * *",
+			JobTemplate: batchv1.JobTemplateSpec{Spec: jobSpec},
+		},
+	}
+	clientset := newFakeClientset("1", "29", cj)
+
+	job, err := newJobFromCluster(clientset, "test", jobOptions{})
+	if err != nil {
+		t.Fatalf("newJob got error: %v", err)
+	}
+
+	if !regexp.MustCompile(`^test-[a-z0-9]{6}$`).MatchString(job.Name) {
+		t.Errorf(`job name expected "test-xxxxxx", got "%s"`, job.Name)
+	}
+	if job.Namespace != "default" {
+		t.Errorf(`job namespace expected "default", got "%s"`, job.Namespace)
+	}
+
+	expectOwnerRefs := []metav1.OwnerReference{
+		{
+			APIVersion:         "batch/v1",
+			Kind:               "CronJob",
+			Name:               "test",
+			UID:                "uid",
+			BlockOwnerDeletion: toPtr(true),
+		},
+	}
+	if diff := cmp.Diff(expectOwnerRefs, job.OwnerReferences); diff != "" {
+		t.Errorf("ownerReferences diff (-expect, +got)\n%s", diff)
+	}
+	expectSpec := jobSpec.DeepCopy()
+	expectSpec.Template.Labels = map[string]string{jobNameLabel: job.Name}
+	if diff := cmp.Diff(*expectSpec, job.Spec); diff != "" {
+		t.Errorf("spec diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestNewJob_PodTemplateLabels(t *testing.T) {
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec: batchv1.CronJobSpec{
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "report"}},
+					},
+				},
+			},
+		},
+	}
+	clientset := newFakeClientset("1", "29", cj)
+
+	job, err := newJobFromCluster(clientset, "test", jobOptions{})
+	if err != nil {
+		t.Fatalf("newJob got error: %v", err)
+	}
+
+	expect := map[string]string{"app": "report", jobNameLabel: job.Name}
+	if diff := cmp.Diff(expect, job.Spec.Template.Labels); diff != "" {
+		t.Errorf("pod template labels diff (-expect, +got)\n%s", diff)
+	}
+	if _, ok := cj.Spec.JobTemplate.Spec.Template.Labels[jobNameLabel]; ok {
+		t.Error("the labels of the CronJob should not be modified")
+	}
+}
+
+func TestNewJob_NoSuffix(t *testing.T) {
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "report"},
+	}
+
+	job, err := newJob(cj, jobOptions{noSuffix: true})
+	if err != nil {
+		t.Fatalf("newJob got error: %v", err)
+	}
+	if job.Name != "report" {
+		t.Errorf(`job name expected "report", got "%s"`, job.Name)
+	}
+	if got := job.Spec.Template.Labels[jobNameLabel]; got != "report" {
+		t.Errorf(`job-name label expected "report", got "%s"`, got)
+	}
+}
+
+func TestNewJob_InvalidName(t *testing.T) {
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "daily_report"},
+	}
+
+	for _, opts := range []jobOptions{{}, {generateName: true}} {
+		_, err := newJob(cj, opts)
+		if err == nil {
+			t.Fatalf("newJob should return error for the name with an underscore (generateName=%t)", opts.generateName)
+		}
+		if !strings.Contains(err.Error(), `generated from "daily_report" is invalid`) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestNewJob_TargetNamespace(t *testing.T) {
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "uid"},
+	}
+
+	tests := map[string]struct {
+		targetNamespace string
+		expectNamespace string
+		expectOwnerRefs int
+	}{
+		"not set": {
+			expectNamespace: "default",
+			expectOwnerRefs: 1,
+		},
+		"same as the source": {
+			targetNamespace: "default",
+			expectNamespace: "default",
+			expectOwnerRefs: 1,
+		},
+		"different from the source": {
+			targetNamespace: "sandbox",
+			expectNamespace: "sandbox",
+			expectOwnerRefs: 0,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			job, err := newJob(cj, jobOptions{targetNamespace: tt.targetNamespace})
+			if err != nil {
+				t.Fatalf("newJob got error: %v", err)
+			}
+			if job.Namespace != tt.expectNamespace {
+				t.Errorf(`namespace expected "%s", got "%s"`, tt.expectNamespace, job.Namespace)
+			}
+			if len(job.OwnerReferences) != tt.expectOwnerRefs {
+				t.Errorf("ownerReferences expected %d entries, got %v", tt.expectOwnerRefs, job.OwnerReferences)
+			}
+		})
+	}
+}
+
+func TestNewJobFromJob(t *testing.T) {
+	src := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "migrate", UID: "uid"},
+		Spec: batchv1.JobSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"batch.kubernetes.io/controller-uid": "uid"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					"app":                                "migrate",
+					"controller-uid":                     "uid",
+					"batch.kubernetes.io/controller-uid": "uid",
+				}},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+			},
+		},
+		Status: batchv1.JobStatus{Succeeded: 1},
+	}
+	clientset := newFakeClientset("1", "29", src)
+	a := app{from: "job", clientOpts: clientOptions{timeout: 5 * time.Second}}
+
+	job, err := a.newJob(context.Background(), clientset, true, "default", "migrate")
+	if err != nil {
+		t.Fatalf("newJob got error: %v", err)
+	}
+
+	if !regexp.MustCompile(`^migrate-[a-z0-9]{6}$`).MatchString(job.Name) {
+		t.Errorf(`job name expected "migrate-xxxxxx", got "%s"`, job.Name)
+	}
+	if job.Spec.Selector != nil {
+		t.Errorf("selector should be removed, got %v", job.Spec.Selector)
+	}
+	if len(job.OwnerReferences) != 0 {
+		t.Errorf("ownerReferences should be empty, got %v", job.OwnerReferences)
+	}
+	if diff := cmp.Diff(batchv1.JobStatus{}, job.Status); diff != "" {
+		t.Errorf("status diff (-expect, +got)\n%s", diff)
+	}
+	expect := map[string]string{"app": "migrate", jobNameLabel: job.Name}
+	if diff := cmp.Diff(expect, job.Spec.Template.Labels); diff != "" {
+		t.Errorf("pod template labels diff (-expect, +got)\n%s", diff)
+	}
+	if _, ok := src.Spec.Template.Labels["controller-uid"]; !ok {
+		t.Error("the labels of the source Job should not be modified")
+	}
+}
+
+func TestNormalizeClonedSpec(t *testing.T) {
+	spec := batchv1.JobSpec{
+		ManualSelector: toPtr(true),
+		Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"controller-uid": "uid"}},
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				"app":                                "migrate",
+				"controller-uid":                     "uid",
+				"batch.kubernetes.io/controller-uid": "uid",
+				"job-name":                           "migrate",
+				"batch.kubernetes.io/job-name":       "migrate",
+			}},
+		},
+	}
+
+	normalizeClonedSpec(&spec)
+
+	if spec.Selector != nil || spec.ManualSelector != nil {
+		t.Errorf("selector and manualSelector should be removed, got %v and %v", spec.Selector, spec.ManualSelector)
+	}
+	expect := map[string]string{"app": "migrate"}
+	if diff := cmp.Diff(expect, spec.Template.Labels); diff != "" {
+		t.Errorf("pod template labels diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestNewJobTemplate_VersionBranching(t *testing.T) {
+	podSpec := corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}}
+	objectMeta := metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "uid"}
+
+	tests := map[string]struct {
+		minor      string
+		object     runtime.Object
+		apiVersion string
+	}{
+		"1.20 uses batch/v1beta1": {
+			minor: "20",
+			object: &batchv1beta1.CronJob{
+				ObjectMeta: objectMeta,
+				Spec: batchv1beta1.CronJobSpec{
+					JobTemplate: batchv1beta1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: podSpec}},
+					},
+				},
+			},
+			apiVersion: "batch/v1beta1",
+		},
+		"1.25 uses batch/v1": {
+			minor: "25",
+			object: &batchv1.CronJob{
+				ObjectMeta: objectMeta,
+				Spec: batchv1.CronJobSpec{
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: podSpec}},
+					},
+				},
+			},
+			apiVersion: "batch/v1",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			clientset := newFakeClientset("1", tt.minor, tt.object)
+
+			ga, err := serverCronJobGA(clientset)
+			if err != nil {
+				t.Fatalf("serverCronJobGA got error: %v", err)
+			}
+			cj, err := findCronJob(context.Background(), clientset, ga, "default", "test")
+			if err != nil {
+				t.Fatalf("findCronJob got error: %v", err)
+			}
+			jobSpec, ownerRef := newJobTemplate(cj)
+			if ownerRef.APIVersion != tt.apiVersion {
+				t.Errorf(`ownerReference apiVersion expected "%s", got "%s"`, tt.apiVersion, ownerRef.APIVersion)
+			}
+			if diff := cmp.Diff(podSpec, jobSpec.Template.Spec); diff != "" {
+				t.Errorf("pod spec diff (-expect, +got)\n%s", diff)
+			}
+
+			var gets []string
+			for _, a := range clientset.Actions() {
+				if a.GetVerb() == "get" && a.GetResource().Resource == "cronjobs" {
+					gets = append(gets, a.GetResource().GroupVersion().String())
+				}
+			}
+			if diff := cmp.Diff([]string{tt.apiVersion}, gets); diff != "" {
+				t.Errorf("cronjob get api versions diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApp_CreateJobs_ServerVersionOnce(t *testing.T) {
+	newCronJob := func(name string) *batchv1.CronJob {
+		return &batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: batchv1.CronJobSpec{
+				JobTemplate: batchv1.JobTemplateSpec{
+					Spec: batchv1.JobSpec{
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers:    []corev1.Container{{Name: "app", Image: "app:v1"}},
+								RestartPolicy: corev1.RestartPolicyNever,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	clientset := newFakeClientset("1", "29", newCronJob("a"), newCronJob("b"))
+
+	a := &app{
+		clientOpts: clientOptions{timeout: 5 * time.Second},
+		creator: jobCreator{
+			info:   io.Discard,
+			noEdit: true,
+			yes:    true,
+			applier: func(ctx context.Context, args []string) (string, error) {
+				return "", nil
+			},
+		},
+	}
+	if err := a.createJobs(clientset, nil, []string{"default/a", "default/b"}); err != nil {
+		t.Fatalf("createJobs got error: %v", err)
+	}
+
+	var versionCalls int
+	for _, a := range clientset.Actions() {
+		if a.GetVerb() == "get" && a.GetResource().Resource == "version" {
+			versionCalls++
+		}
+	}
+	if versionCalls != 1 {
+		t.Errorf("ServerVersion expected to be called once for 2 cronjobs, got %d", versionCalls)
+	}
+}
+
+func TestJobName(t *testing.T) {
+	tests := map[string]struct {
+		prefix string
+		expect string
+	}{
+		"short name": {
+			prefix: "test",
+			expect: "test-abc123",
+		},
+		"long name is truncated": {
+			prefix: strings.Repeat("a", 60),
+			expect: strings.Repeat("a", 56) + "-abc123",
+		},
+		"trailing hyphen after truncation is trimmed": {
+			prefix: strings.Repeat("a", 55) + "-bbbb",
+			expect: strings.Repeat("a", 55) + "-abc123",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got := jobName(tt.prefix, "abc123")
+			if got != tt.expect {
+				t.Errorf(`jobName expected "%s", got "%s"`, tt.expect, got)
+			}
+			if len(got) > maxJobNameLength {
+				t.Errorf("jobName length should be <= %d, got %d", maxJobNameLength, len(got))
+			}
+			if !strings.HasSuffix(got, "-abc123") {
+				t.Errorf(`jobName should keep the suffix, got "%s"`, got)
+			}
+		})
+	}
+}
+
+func TestNewJob_GenerateName(t *testing.T) {
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+	}
+	clientset := newFakeClientset("1", "29", cj)
+
+	job, err := newJobFromCluster(clientset, "test", jobOptions{generateName: true})
+	if err != nil {
+		t.Fatalf("newJob got error: %v", err)
+	}
+	if job.Name != "" {
+		t.Errorf(`job name expected to be empty, got "%s"`, job.Name)
+	}
+	if job.GenerateName != "test-" {
+		t.Errorf(`job generateName expected "test-", got "%s"`, job.GenerateName)
+	}
+}
+
+func TestCleanupOnSignal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "kj.*.yaml")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	f.Close()
+
+	exited := make(chan int, 1)
+	stop := cleanupOnSignal(f.Name(), func(code int) { exited <- code })
+	defer stop()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("failed to send SIGTERM: %v", err)
+	}
+
+	select {
+	case code := <-exited:
+		if code != exitStatusErr {
+			t.Errorf("exit code expected %d, got %d", exitStatusErr, code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cleanupOnSignal didn't exit on SIGTERM")
+	}
+
+	if _, err := os.Stat(f.Name()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temp file should be removed, got stat error: %v", err)
+	}
+}
+
+func TestCreatePrivateFile(t *testing.T) {
+	tests := map[string]struct {
+		prepare func(t *testing.T, filename string)
+	}{
+		"new file": {
+			prepare: func(t *testing.T, filename string) {},
+		},
+		"existing file with wider mode": {
+			prepare: func(t *testing.T, filename string) {
+				if err := os.WriteFile(filename, []byte("old"), 0o644); err != nil {
+					t.Fatalf("failed to write file: %v", err)
+				}
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "job.yaml")
+			tt.prepare(t, filename)
+
+			f, err := createPrivateFile(filename)
+			if err != nil {
+				t.Fatalf("createPrivateFile got error: %v", err)
+			}
+			if _, err := f.WriteString("kind: Job\n"); err != nil {
+				t.Fatalf("failed to write: %v", err)
+			}
+			f.Close()
+
+			info, err := os.Stat(filename)
+			if err != nil {
+				t.Fatalf("failed to stat: %v", err)
+			}
+			if mode := info.Mode().Perm(); mode != manifestFileMode {
+				t.Errorf("file mode expected %o, got %o", manifestFileMode, mode)
+			}
+		})
+	}
+}
+
+func TestLookKubectl(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		got, err := lookKubectl("kubectl", func(string) (string, error) { return "/usr/local/bin/kubectl", nil })
+		if err != nil {
+			t.Fatalf("lookKubectl got error: %v", err)
+		}
+		if got != "/usr/local/bin/kubectl" {
+			t.Errorf(`lookKubectl expected "/usr/local/bin/kubectl", got "%s"`, got)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := lookKubectl("kubectl", func(string) (string, error) { return "", exec.ErrNotFound })
+		if err == nil {
+			t.Fatal("lookKubectl should return error when kubectl is not found")
+		}
+		if !errors.Is(err, exec.ErrNotFound) {
+			t.Errorf("lookKubectl error should wrap exec.ErrNotFound, got %v", err)
+		}
+		for _, want := range []string{"kubectl is not found", "Install kubectl", "-kubectl-path"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("lookKubectl error should contain %q, got %q", want, err.Error())
+			}
+		}
+	})
+}
+
+func TestReplaceExistingJob(t *testing.T) {
+	existing := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "existing"},
+	}
+
+	tests := map[string]struct {
+		name            string
+		replace         bool
+		wantExistsError bool
+		wantDeleted     bool
+	}{
+		"new job": {
+			name: "new",
+		},
+		"existing job without -replace": {
+			name:            "existing",
+			wantExistsError: true,
+		},
+		"existing job with -replace": {
+			name:        "existing",
+			replace:     true,
+			wantDeleted: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(existing.DeepCopy())
+			job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: tt.name}}
+
+			err := replaceExistingJob(context.Background(), clientset, job, tt.replace)
+			if tt.wantExistsError {
+				if !apierrors.IsAlreadyExists(err) {
+					t.Errorf("replaceExistingJob expected AlreadyExists error, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("replaceExistingJob got error: %v", err)
+			}
+
+			var deleted bool
+			for _, a := range clientset.Actions() {
+				if d, ok := a.(k8stesting.DeleteAction); ok {
+					deleted = true
+					if d.GetName() != "existing" {
+						t.Errorf(`deleted job expected "existing", got "%s"`, d.GetName())
+					}
+					opts := d.GetDeleteOptions()
+					if opts.PropagationPolicy == nil || *opts.PropagationPolicy != metav1.DeletePropagationBackground {
+						t.Errorf("propagationPolicy expected Background, got %v", opts.PropagationPolicy)
+					}
+				}
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("deleted expected %t, got %t", tt.wantDeleted, deleted)
+			}
+		})
+	}
+}
+
+func TestGetTargets(t *testing.T) {
+	tests := map[string]struct {
+		inputs []string
+		expect []target
+		ok     bool
+	}{
+		"namespace and name is separated": {
+			inputs: []string{"namespace", "name"},
+			expect: []target{{namespace: "namespace", name: "name"}},
+			ok:     true,
+		},
+		"single name": {
+			inputs: []string{"name"},
+			expect: []target{{name: "name"}},
+			ok:     true,
+		},
+		"multiple targets": {
+			inputs: []string{"ns1/a", "ns2/b", "ns1/c"},
+			expect: []target{{namespace: "ns1", name: "a"}, {namespace: "ns2", name: "b"}, {namespace: "ns1", name: "c"}},
+			ok:     true,
+		},
+		"two targets with namespace": {
+			inputs: []string{"ns1/a", "ns2/b"},
+			expect: []target{{namespace: "ns1", name: "a"}, {namespace: "ns2", name: "b"}},
+			ok:     true,
+		},
+		"bare name mixed with multiple targets": {
+			inputs: []string{"ns1/a", "b"},
+			ok:     false,
+		},
+		"three bare names are not namespace name": {
+			inputs: []string{"ns", "a", "b"},
+			ok:     false,
+		},
+		"namespace name mixed with multiple targets": {
+			inputs: []string{"ns1/a", "ns2", "b"},
+			ok:     false,
+		},
+		"name is empty": {
+			inputs: []string{"ns1/a", "ns2/"},
+			ok:     false,
+		},
+		"only namespace": {
+			inputs: []string{"ns/"},
+			expect: []target{{namespace: "ns"}},
+			ok:     true,
+		},
+		"separator is too many": {
+			inputs: []string{"ns1/a", "ns/name/hello"},
+			ok:     false,
+		},
+		"inputs are nil": {
+			inputs: nil,
+			ok:     false,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got, ok := getTargets(tt.inputs)
+			if ok != tt.ok {
+				t.Fatalf("ok expected %t, got %t", tt.ok, ok)
+			}
+			if diff := cmp.Diff(tt.expect, got, cmp.AllowUnexported(target{})); diff != "" {
+				t.Errorf("getTargets diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheckNamespace(t *testing.T) {
+	tests := map[string]struct {
+		edited  string
+		strict  bool
+		warned  bool
+		wantErr bool
+	}{
+		"same namespace": {
+			edited: "default",
+		},
+		"changed namespace is warned": {
+			edited: "other",
+			warned: true,
+		},
+		"changed namespace is error with strict": {
+			edited:  "other",
+			strict:  true,
+			wantErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := checkNamespace(&buf, tt.edited, "default", tt.strict)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkNamespace expected error %t, got %v", tt.wantErr, err)
+			}
+			if warned := buf.Len() > 0; warned != tt.warned {
+				t.Errorf("checkNamespace expected warning %t, got %q", tt.warned, buf.String())
+			}
+		})
+	}
+}
+
+func TestJobCreator_Quiet(t *testing.T) {
+	var info bytes.Buffer
+	c := &jobCreator{info: &info}
+	c.setQuiet()
+
+	var stdout bytes.Buffer
+	c.printApplied(&stdout, "job.batch/test-abc123\n")
+	fmt.Fprintln(c.info, "saved the manifest to /tmp/test.yaml")
+
+	if got := stdout.String(); got != "test-abc123\n" {
+		t.Errorf(`output expected only the name, got %q`, got)
+	}
+	if info.Len() != 0 {
+		t.Errorf("info output expected to be empty in quiet mode, got %q", info.String())
+	}
+}
+
+func TestApp_Validate(t *testing.T) {
+	tests := map[string]struct {
+		app     app
+		wantErr bool
+	}{
+		"default": {
+			app: app{},
+		},
+		"unknown output": {
+			app:     app{creator: jobCreator{output: "yaml"}},
+			wantErr: true,
+		},
+		"quiet without yes": {
+			app:     app{quiet: true},
+			wantErr: true,
+		},
+		"quiet with yes": {
+			app: app{quiet: true, creator: jobCreator{yes: true}},
+		},
+		"force-conflicts without server-side": {
+			app:     app{creator: jobCreator{forceConflicts: true}},
+			wantErr: true,
+		},
+		"server-side with smart-apply": {
+			app:     app{creator: jobCreator{serverSide: true, smartApply: true}},
+			wantErr: true,
+		},
+		"no-suffix with server-side": {
+			app:     app{creator: jobCreator{serverSide: true}, opts: jobOptions{noSuffix: true}},
+			wantErr: true,
+		},
+		"no-suffix with replace": {
+			app: app{creator: jobCreator{replace: true}, opts: jobOptions{noSuffix: true}},
+		},
+		"replace with generate-name": {
+			app:     app{creator: jobCreator{replace: true}, opts: jobOptions{generateName: true}},
+			wantErr: true,
+		},
+		"invalid job options": {
+			app:     app{opts: jobOptions{parallelism: 2, completions: 1}},
+			wantErr: true,
+		},
+		"timezone": {
+			app: app{timezone: "Asia/Tokyo"},
+		},
+		"unknown timezone": {
+			app:     app{timezone: "Asia/Nowhere"},
+			wantErr: true,
+		},
+		"wait-timeout without wait": {
+			app:     app{waitTimeout: time.Minute},
+			wantErr: true,
+		},
+		"wait with wait-timeout": {
+			app: app{wait: true, waitTimeout: time.Minute},
+		},
+		"watch-events with port-forward": {
+			app:     app{watchEvents: true, portForward: "8080:80"},
+			wantErr: true,
+		},
+		"from job": {
+			app: app{from: "job"},
+		},
+		"unknown from": {
+			app:     app{from: "pod"},
+			wantErr: true,
+		},
+		"from job with selector": {
+			app:     app{from: "job", selector: "app=report"},
+			wantErr: true,
+		},
+		"concurrency": {
+			app: app{concurrency: 8},
+		},
+		"negative concurrency": {
+			app:     app{concurrency: -1},
+			wantErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			err := tt.app.validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApp_CreateJobs_InvalidArguments(t *testing.T) {
+	a := &app{}
+	err := a.createJobs(newFakeClientset("1", "29"), nil, []string{"ns/name/extra"})
+	if !errors.Is(err, errInvalidArguments) {
+		t.Errorf("createJobs error expected errInvalidArguments, got %v", err)
+	}
+}
+
+func TestPrintError(t *testing.T) {
+	var buf bytes.Buffer
+	printError(&buf, errors.Join(errors.New("a is not found"), errors.New("b is not found")))
+
+	expect := "kj: a is not found\nkj: b is not found\n"
+	if diff := cmp.Diff(expect, buf.String()); diff != "" {
+		t.Errorf("printError diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestJobCreator_CreateWithFileName_TempDir(t *testing.T) {
+	tempDir := t.TempDir()
+	job := &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-abcdef"},
+		Spec: batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+			},
+		},
+	}
+
+	var editedDir string
+	c := &jobCreator{
+		tempDir: tempDir,
+		info:    io.Discard,
+		editor: func(filename string) error {
+			editedDir = filepath.Dir(filename)
+			return nil
+		},
+		confirmer: func() (bool, error) { return false, nil },
+	}
+	if _, err := c.createWithFileName("", job); err != nil {
+		t.Fatalf("createWithFileName got error: %v", err)
+	}
+	if editedDir != tempDir {
+		t.Errorf(`temp file expected in "%s", got "%s"`, tempDir, editedDir)
+	}
+
+	if err := checkWritableDir(filepath.Join(tempDir, "missing")); err == nil {
+		t.Error("checkWritableDir should return error for a missing directory")
+	}
+}
+
+func TestJobCreator_Create_DiffOnInfo(t *testing.T) {
+	job := &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-abcdef"},
+		Spec: batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+			},
+		},
+	}
+
+	// printApplied writes to os.Stdout, so it is replaced to see what is printed there.
+	stdout, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+	orig := os.Stdout
+	os.Stdout = stdout
+	defer func() { os.Stdout = orig }()
+
+	var info bytes.Buffer
+	c := &jobCreator{
+		info:       &info,
+		output:     "name",
+		diffFormat: "text",
+		editor: func(filename string) error {
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+			data = bytes.Replace(data, []byte("image: app:v1"), []byte("image: app:debug"), 1)
+			return os.WriteFile(filename, data, 0o600)
+		},
+		confirmer: func() (bool, error) { return true, nil },
+		applier: func(ctx context.Context, args []string) (string, error) {
+			return "job.batch/test-abcdef\n", nil
+		},
+	}
+	if _, err := c.createWithFileName("", job); err != nil {
+		t.Fatalf("createWithFileName got error: %v", err)
+	}
+	os.Stdout = orig
+
+	got, err := os.ReadFile(stdout.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("test-abcdef\n", string(got)); diff != "" {
+		t.Errorf("stdout expected to have only the applied job, diff (-expect, +got)\n%s", diff)
+	}
+	if !strings.Contains(info.String(), "app:debug") {
+		t.Errorf("the diff expected on info, got\n%s", info.String())
+	}
+}
+
+func TestJobCreator_Create_NamespaceRemoved(t *testing.T) {
+	job := &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "batch", Name: "test-abcdef"},
+		Spec: batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+			},
+		},
+	}
+
+	var appliedArgs []string
+	c := &jobCreator{
+		info: io.Discard,
+		editor: func(filename string) error {
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+			data = bytes.Replace(data, []byte("  namespace: batch\n"), nil, 1)
+			return os.WriteFile(filename, data, 0o600)
+		},
+		confirmer: func() (bool, error) { return true, nil },
+		applier: func(ctx context.Context, args []string) (string, error) {
+			appliedArgs = args
+			return "", nil
+		},
+	}
+	applied, err := c.createWithFileName("", job)
+	if err != nil {
+		t.Fatalf("createWithFileName got error: %v", err)
+	}
+
+	// Without --namespace, kubectl applies the Job to the namespace of the kubeconfig context.
+	if len(appliedArgs) < 5 || appliedArgs[3] != "--namespace" || appliedArgs[4] != "batch" {
+		t.Errorf(`kubectl args expected "apply -f <file> --namespace batch", got %v`, appliedArgs)
+	}
+	if applied.Namespace != "batch" {
+		t.Errorf(`applied job expected in namespace "batch", got "%s"`, applied.Namespace)
+	}
+}
+
+func TestJobCreator_Create_RenamedJob(t *testing.T) {
+	job, err := buildJob("default", "test", batchv1.JobSpec{
+		Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+		},
+	}, nil, jobOptions{noSuffix: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var appliedManifest string
+	c := &jobCreator{
+		info: io.Discard,
+		editor: func(filename string) error {
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+			data = bytes.Replace(data, []byte("name: test\n"), []byte("name: test-debug\n"), 1)
+			return os.WriteFile(filename, data, 0o600)
+		},
+		confirmer: func() (bool, error) { return true, nil },
+		applier: func(ctx context.Context, args []string) (string, error) {
+			data, err := os.ReadFile(args[2])
+			if err != nil {
+				return "", err
+			}
+			appliedManifest = string(data)
+			return "", nil
+		},
+	}
+	applied, err := c.createWithFileName("", job)
+	if err != nil {
+		t.Fatalf("createWithFileName got error: %v", err)
+	}
+
+	if applied.Name != "test-debug" {
+		t.Fatalf(`the job expected to be renamed to "test-debug", got "%s"`, applied.Name)
+	}
+	if got := applied.Spec.Template.Labels[jobNameLabel]; got != "test-debug" {
+		t.Errorf(`%s label expected to follow the renamed job "test-debug", got "%s"`, jobNameLabel, got)
+	}
+	if !strings.Contains(appliedManifest, jobNameLabel+": test-debug") {
+		t.Errorf("applied manifest expected to have the refreshed label, got\n%s", appliedManifest)
+	}
+}
+
+func TestApp_CreateJobs(t *testing.T) {
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "uid"},
+		Spec: batchv1.CronJobSpec{
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers:    []corev1.Container{{Name: "app", Image: "app:v1"}},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			},
+		},
+	}
+	clientset := newFakeClientset("1", "29", cj)
+
+	var appliedArgs []string
+	var appliedManifest string
+	a := &app{
+		clientOpts: clientOptions{timeout: 5 * time.Second},
+		creator: jobCreator{
+			info:         io.Discard,
+			fieldManager: "kj",
+			editor: func(filename string) error {
+				data, err := os.ReadFile(filename)
+				if err != nil {
+					return err
+				}
+				data = bytes.Replace(data, []byte("image: app:v1"), []byte("image: app:debug"), 1)
+				return os.WriteFile(filename, data, 0o600)
+			},
+			confirmer: func() (bool, error) { return true, nil },
+			applier: func(ctx context.Context, args []string) (string, error) {
+				appliedArgs = args
+				data, err := os.ReadFile(args[2])
+				if err != nil {
+					return "", err
+				}
+				appliedManifest = string(data)
+				return "", nil
+			},
+		},
+	}
+
+	if err := a.createJobs(clientset, nil, []string{"default/test"}); err != nil {
+		t.Fatalf("createJobs got error: %v", err)
+	}
+
+	if len(appliedArgs) < 3 || appliedArgs[0] != "apply" || appliedArgs[1] != "-f" {
+		t.Fatalf(`kubectl args expected "apply -f <file> ...", got %v`, appliedArgs)
+	}
+	if !strings.Contains(appliedManifest, "image: app:debug") {
+		t.Errorf("applied manifest expected to have the edited image, got\n%s", appliedManifest)
+	}
+	if !strings.Contains(appliedManifest, "namespace: default") {
+		t.Errorf("applied manifest expected to have the namespace, got\n%s", appliedManifest)
+	}
+}
+
+func TestForEachTarget(t *testing.T) {
+	targets := []target{{name: "a"}, {name: "b"}, {name: "c"}, {name: "d"}, {name: "e"}}
+
+	var mu sync.Mutex
+	var running, maxRunning int
+	err := forEachTarget(targets, 2, func(t target) error {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		if t.name == "b" || t.name == "d" {
+			return fmt.Errorf("%s failed", t.name)
+		}
+		return nil
+	})
+
+	if maxRunning != 2 {
+		t.Errorf("forEachTarget expected to run 2 at once, got %d", maxRunning)
+	}
+	expect := "b failed\nd failed"
+	if err == nil || err.Error() != expect {
+		t.Errorf("forEachTarget expected the errors in the order of the targets %q, got %v", expect, err)
+	}
+}
+
+func TestApp_CreateJobs_NoSuffixAlreadyExists(t *testing.T) {
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec: batchv1.CronJobSpec{
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+					},
+				},
+			},
+		},
+	}
+	existing := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"}}
+
+	a := &app{
+		clientOpts: clientOptions{timeout: 5 * time.Second},
+		opts:       jobOptions{noSuffix: true},
+		creator: jobCreator{
+			noSuffix:  true,
+			info:      io.Discard,
+			editor:    func(string) error { return nil },
+			confirmer: func() (bool, error) { return true, nil },
+			applier: func(context.Context, []string) (string, error) {
+				t.Fatal("kubectl should not be called when the Job exists")
+				return "", nil
+			},
+		},
+	}
+	err := a.createJobs(newFakeClientset("1", "29", cj, existing), nil, []string{"default/test"})
+	if !apierrors.IsAlreadyExists(err) {
+		t.Fatalf("createJobs expected AlreadyExists, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "-replace") {
+		t.Errorf("the error should suggest -replace, got %v", err)
+	}
+}
+
+func TestApp_CreateJobs_NoEditWithoutTTY(t *testing.T) {
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec: batchv1.CronJobSpec{
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+					},
+				},
+			},
+		},
+	}
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	kubectl := filepath.Join(dir, "kubectl")
+	body := "#!/bin/sh\necho \"$@\" > " + argsFile + "\n"
+	if err := os.WriteFile(kubectl, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The real kubectl is run, so the terminal must not be required with -no-edit -yes.
+	a := &app{
+		clientOpts: clientOptions{timeout: 5 * time.Second},
+		creator: jobCreator{
+			kubectlPath: kubectl,
+			noEdit:      true,
+			yes:         true,
+			output:      "name",
+			info:        io.Discard,
+		},
+	}
+	if err := a.createJobs(newFakeClientset("1", "29", cj), nil, []string{"default/test"}); err != nil {
+		t.Fatalf("createJobs got error: %v", err)
+	}
+	if a.creator.tty != nil {
+		t.Error("the terminal should not be opened with -no-edit -yes")
+	}
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("kubectl should be called: %v", err)
+	}
+	if !strings.HasPrefix(string(args), "apply -f ") {
+		t.Errorf(`kubectl args expected "apply -f <file> ...", got %q`, args)
+	}
+}
+
+func TestApp_CreateJobs_Canceled(t *testing.T) {
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec: batchv1.CronJobSpec{
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+					},
+				},
+			},
+		},
+	}
+
+	a := &app{
+		clientOpts: clientOptions{timeout: 5 * time.Second},
+		creator: jobCreator{
+			info:      io.Discard,
+			editor:    func(string) error { return nil },
+			confirmer: func() (bool, error) { return false, nil },
+			applier: func(context.Context, []string) (string, error) {
+				t.Fatal("kubectl should not be called when the confirmation is canceled")
+				return "", nil
+			},
+		},
+	}
+	if err := a.createJobs(newFakeClientset("1", "29", cj), nil, []string{"default/test"}); err != nil {
+		t.Fatalf("createJobs got error: %v", err)
+	}
+}
+
+func TestApp_CreateJobs_OutputDir(t *testing.T) {
+	newCronJob := func(namespace, name string) *batchv1.CronJob {
+		return &batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec: batchv1.CronJobSpec{
+				JobTemplate: batchv1.JobTemplateSpec{
+					Spec: batchv1.JobSpec{
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: name, Image: name + ":v1"}}},
+						},
+					},
+				},
+			},
+		}
+	}
+	clientset := newFakeClientset("1", "29", newCronJob("ns1", "a"), newCronJob("ns2", "b"))
+
+	dir := filepath.Join(t.TempDir(), "out")
+	a := &app{
+		clientOpts: clientOptions{timeout: 5 * time.Second},
+		outputDir:  dir,
+		creator: jobCreator{
+			info: io.Discard,
+			applier: func(context.Context, []string) (string, error) {
+				t.Fatal("jobs should not be applied with -output-dir")
+				return "", nil
+			},
+		},
+	}
+	if err := a.createJobs(clientset, nil, []string{"ns1/a", "ns2/b"}); err != nil {
+		t.Fatalf("createJobs got error: %v", err)
+	}
+
+	for file, image := range map[string]string{"ns1-a.yaml": "image: a:v1", "ns2-b.yaml": "image: b:v1"} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Errorf("%s should be written: %v", file, err)
+			continue
+		}
+		if !strings.Contains(string(data), image) {
+			t.Errorf("%s expected to contain %q, got\n%s", file, image, data)
+		}
+	}
+}
+
+func TestJobToYaml_TopLevelKeyOrder(t *testing.T) {
+	job := &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+			},
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		data, err := jobToYaml(job.DeepCopy(), false)
+		if err != nil {
+			t.Fatalf("jobToYaml got error: %v", err)
+		}
+
+		var keys []string
+		for _, line := range strings.Split(string(data), "\n") {
+			if line != "" && line[0] != ' ' && line[0] != '-' {
+				key, _, _ := strings.Cut(line, ":")
+				keys = append(keys, key)
+			}
+		}
+		if diff := cmp.Diff([]string{"apiVersion", "kind", "metadata", "spec"}, keys); diff != "" {
+			t.Errorf("top-level keys diff (-expect, +got)\n%s", diff)
+		}
+	}
+}
+
+func TestApp_CreateJobs_EditFormatJSON(t *testing.T) {
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec: batchv1.CronJobSpec{
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+					},
+				},
+			},
+		},
+	}
+
+	var editedFile string
+	var applied batchv1.Job
+	a := &app{
+		clientOpts: clientOptions{timeout: 5 * time.Second},
+		creator: jobCreator{
+			info:       io.Discard,
+			editFormat: "json",
+			editor: func(filename string) error {
+				editedFile = filename
+				data, err := os.ReadFile(filename)
+				if err != nil {
+					return err
+				}
+				var job batchv1.Job
+				if err := json.Unmarshal(data, &job); err != nil {
+					return fmt.Errorf("the file to edit should be JSON: %w", err)
+				}
+				job.Spec.Template.Spec.Containers[0].Image = "app:debug"
+				data, err = json.MarshalIndent(job, "", "  ")
+				if err != nil {
+					return err
+				}
+				return os.WriteFile(filename, data, 0o600)
+			},
+			confirmer: func() (bool, error) { return true, nil },
+			applier: func(ctx context.Context, args []string) (string, error) {
+				data, err := os.ReadFile(args[2])
+				if err != nil {
+					return "", err
+				}
+				return "", json.Unmarshal(data, &applied)
+			},
+		},
+	}
+	if err := a.createJobs(newFakeClientset("1", "29", cj), nil, []string{"default/test"}); err != nil {
+		t.Fatalf("createJobs got error: %v", err)
+	}
+
+	if filepath.Ext(editedFile) != ".json" {
+		t.Errorf(`file to edit expected to have ".json" extension, got "%s"`, editedFile)
+	}
+	if got := applied.Spec.Template.Spec.Containers[0].Image; got != "app:debug" {
+		t.Errorf(`applied image expected "app:debug", got "%s"`, got)
+	}
+}
+
+func TestTempFilePattern(t *testing.T) {
+	tests := map[string]struct {
+		job    *batchv1.Job
+		expect string
+	}{
+		"name": {
+			job:    &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-abc123"}},
+			expect: "kj.default.test-abc123.*.yaml",
+		},
+		"generateName": {
+			job:    &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", GenerateName: "test-"}},
+			expect: "kj.default.test.*.yaml",
+		},
+		"invalid characters are sanitized": {
+			job:    &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "a/b", Name: "c*d:e"}},
+			expect: "kj.a_b.c_d_e.*.yaml",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got := tempFilePattern(tt.job, "yaml")
+			if got != tt.expect {
+				t.Errorf(`tempFilePattern expected "%s", got "%s"`, tt.expect, got)
+			}
+		})
+	}
+}
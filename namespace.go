@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// runNamespace prints the namespace which kj targets for the arguments without calling the API.
+func runNamespace(w io.Writer, kubeconfig string, args []string) int {
+	var namespace string
+	if len(args) > 0 {
+		var ok bool
+		namespace, _, ok = getNamespaceAndName(args)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s: argments are invalid\n", cmdName)
+			return exitStatusErr
+		}
+	}
+
+	namespace, err := resolveNamespace(namespace, kubeconfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmdName, err)
+		return exitStatusErr
+	}
+	fmt.Fprintln(w, namespace)
+	return exitStatusOK
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRunNamespace(t *testing.T) {
+	tests := map[string]struct {
+		args   []string
+		expect string
+	}{
+		"context namespace": {
+			args:   nil,
+			expect: "nsB\n",
+		},
+		"name only uses context namespace": {
+			args:   []string{"name"},
+			expect: "nsB\n",
+		},
+		"positional namespace": {
+			args:   []string{"positional/name"},
+			expect: "positional\n",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			var buf bytes.Buffer
+			code := runNamespace(&buf, kubeconfigFilePath, tt.args)
+			if code != exitStatusOK {
+				t.Fatalf("runNamespace expected exit code %d, got %d", exitStatusOK, code)
+			}
+			if buf.String() != tt.expect {
+				t.Errorf(`runNamespace expected "%s", got "%s"`, tt.expect, buf.String())
+			}
+		})
+	}
+}
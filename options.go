@@ -0,0 +1,333 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const sourceCommitAnnotation = "kj.kitagry.dev/source-commit"
+
+// jobOptions are the overrides applied to the Job built from the CronJob template.
+type jobOptions struct {
+	sourceCommit string
+	generateName bool
+	// noSuffix uses the name of the CronJob as it is for the Job.
+	noSuffix    bool
+	images      stringsFlag
+	container   string
+	command     stringsFlag
+	args        stringsFlag
+	env         keyValueFlag
+	parallelism int
+	completions int
+	// restartPolicy is Never or OnFailure. Always is not allowed for Jobs.
+	restartPolicy string
+	nodeSelector  keyValueFlag
+	nodeName      string
+	// serviceAccount must be a DNS-1123 label.
+	serviceAccount string
+	// envFrom is "configmap:name" or "secret:name".
+	envFrom stringsFlag
+	// imagePullPolicy is Always, IfNotPresent or Never.
+	imagePullPolicy string
+	annotations     keyValueFlag
+	labels          keyValueFlag
+	// allContainers applies the container overrides to every container instead of -container.
+	allContainers bool
+	// targetNamespace is the namespace the Job is created in when it differs from the CronJob.
+	targetNamespace string
+}
+
+// validate checks the options which are invalid regardless of the CronJob.
+func (o jobOptions) validate() error {
+	if o.parallelism < 0 || o.completions < 0 {
+		return fmt.Errorf("-parallelism and -completions must not be negative")
+	}
+	if o.parallelism > 0 && o.completions > 0 && o.parallelism > o.completions {
+		return fmt.Errorf("-parallelism %d must be less than or equal to -completions %d", o.parallelism, o.completions)
+	}
+	switch corev1.RestartPolicy(o.restartPolicy) {
+	case "", corev1.RestartPolicyNever, corev1.RestartPolicyOnFailure:
+	default:
+		return fmt.Errorf("-restart-policy must be Never or OnFailure for a Job, got %q", o.restartPolicy)
+	}
+	if o.allContainers {
+		if o.container != "" {
+			return fmt.Errorf("-all-containers can't be used with -container")
+		}
+		for _, image := range o.images {
+			if strings.Contains(image, "=") {
+				return fmt.Errorf("-image %q can't select the container with -all-containers", image)
+			}
+		}
+	}
+	switch corev1.PullPolicy(o.imagePullPolicy) {
+	case "", corev1.PullAlways, corev1.PullIfNotPresent, corev1.PullNever:
+	default:
+		return fmt.Errorf("-image-pull-policy must be Always, IfNotPresent or Never, got %q", o.imagePullPolicy)
+	}
+	for _, s := range o.envFrom {
+		if _, err := parseEnvFrom(s); err != nil {
+			return err
+		}
+	}
+	for _, s := range o.annotations {
+		key, _, _ := strings.Cut(s, "=")
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("-annotation key %q is invalid: %s", key, strings.Join(errs, ", "))
+		}
+	}
+	for _, s := range o.labels {
+		key, value, _ := strings.Cut(s, "=")
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("-label key %q is invalid: %s", key, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("-label value %q is invalid: %s", value, strings.Join(errs, ", "))
+		}
+	}
+	if o.targetNamespace != "" {
+		if errs := validation.IsDNS1123Label(o.targetNamespace); len(errs) > 0 {
+			return fmt.Errorf("-target-namespace %q is invalid: %s", o.targetNamespace, strings.Join(errs, ", "))
+		}
+	}
+	if o.serviceAccount != "" {
+		if errs := validation.IsDNS1123Label(o.serviceAccount); len(errs) > 0 {
+			return fmt.Errorf("-service-account %q is invalid: %s", o.serviceAccount, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+func (o jobOptions) apply(job *batchv1.Job) error {
+	if o.targetNamespace != "" && o.targetNamespace != job.Namespace {
+		job.Namespace = o.targetNamespace
+		// The owner must be in the same namespace as the Job.
+		job.OwnerReferences = nil
+	}
+	if o.sourceCommit != "" {
+		if job.Annotations == nil {
+			job.Annotations = make(map[string]string)
+		}
+		job.Annotations[sourceCommitAnnotation] = o.sourceCommit
+	}
+	job.Annotations = mergeKeyValues(job.Annotations, o.annotations)
+	job.Labels = mergeKeyValues(job.Labels, o.labels)
+
+	if o.parallelism > 0 {
+		job.Spec.Parallelism = toPtr(int32(o.parallelism))
+	}
+	if o.completions > 0 {
+		job.Spec.Completions = toPtr(int32(o.completions))
+	}
+
+	podSpec := &job.Spec.Template.Spec
+	if o.restartPolicy != "" {
+		podSpec.RestartPolicy = corev1.RestartPolicy(o.restartPolicy)
+	}
+	if len(o.nodeSelector) > 0 {
+		// The entries are merged so that the selectors of the template are kept.
+		if podSpec.NodeSelector == nil {
+			podSpec.NodeSelector = make(map[string]string)
+		}
+		for _, s := range o.nodeSelector {
+			key, value, _ := strings.Cut(s, "=")
+			podSpec.NodeSelector[key] = value
+		}
+	}
+	if o.nodeName != "" {
+		podSpec.NodeName = o.nodeName
+	}
+	if o.serviceAccount != "" {
+		podSpec.ServiceAccountName = o.serviceAccount
+	}
+	for _, image := range o.images {
+		if o.allContainers {
+			for i := range podSpec.Containers {
+				podSpec.Containers[i].Image = image
+			}
+			continue
+		}
+		if err := setImage(podSpec, image); err != nil {
+			return err
+		}
+	}
+
+	if len(o.command) > 0 || len(o.args) > 0 {
+		containers, err := o.selectContainers(podSpec)
+		if err != nil {
+			return err
+		}
+		for _, c := range containers {
+			if len(o.command) > 0 {
+				c.Command = o.command
+			}
+			if len(o.args) > 0 {
+				c.Args = o.args
+			}
+		}
+	}
+
+	if len(o.env) > 0 {
+		containers, err := o.selectContainers(podSpec)
+		if err != nil {
+			return err
+		}
+		for _, c := range containers {
+			for _, e := range o.env {
+				key, value, _ := strings.Cut(e, "=")
+				setEnv(c, key, value)
+			}
+		}
+	}
+
+	if o.imagePullPolicy != "" {
+		containers, err := o.selectContainers(podSpec)
+		if err != nil {
+			return err
+		}
+		for _, c := range containers {
+			c.ImagePullPolicy = corev1.PullPolicy(o.imagePullPolicy)
+		}
+	}
+
+	if len(o.envFrom) > 0 {
+		containers, err := o.selectContainers(podSpec)
+		if err != nil {
+			return err
+		}
+		for _, c := range containers {
+			for _, s := range o.envFrom {
+				source, err := parseEnvFrom(s)
+				if err != nil {
+					return err
+				}
+				c.EnvFrom = append(c.EnvFrom, source)
+			}
+		}
+	}
+	return nil
+}
+
+// selectContainers returns every container with -all-containers, otherwise the one selected by -container.
+func (o jobOptions) selectContainers(podSpec *corev1.PodSpec) ([]*corev1.Container, error) {
+	if o.allContainers {
+		containers := make([]*corev1.Container, len(podSpec.Containers))
+		for i := range podSpec.Containers {
+			containers[i] = &podSpec.Containers[i]
+		}
+		return containers, nil
+	}
+	c, err := selectContainer(podSpec, o.container)
+	if err != nil {
+		return nil, err
+	}
+	return []*corev1.Container{c}, nil
+}
+
+// mergeKeyValues sets the KEY=VALUE entries to m, overwriting the existing keys.
+func mergeKeyValues(m map[string]string, entries keyValueFlag) map[string]string {
+	if len(entries) == 0 {
+		return m
+	}
+	if m == nil {
+		m = make(map[string]string, len(entries))
+	}
+	for _, e := range entries {
+		key, value, _ := strings.Cut(e, "=")
+		m[key] = value
+	}
+	return m
+}
+
+// parseEnvFrom parses "configmap:name" or "secret:name" of -env-from.
+func parseEnvFrom(s string) (corev1.EnvFromSource, error) {
+	kind, name, ok := strings.Cut(s, ":")
+	if !ok || name == "" {
+		return corev1.EnvFromSource{}, fmt.Errorf("-env-from %q should be configmap:name or secret:name", s)
+	}
+
+	switch kind {
+	case "configmap":
+		return corev1.EnvFromSource{
+			ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}},
+		}, nil
+	case "secret":
+		return corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}},
+		}, nil
+	}
+	return corev1.EnvFromSource{}, fmt.Errorf("-env-from %q has unknown type %q (available: configmap, secret)", s, kind)
+}
+
+// setEnv replaces the value of the environment variable when it exists, otherwise appends it.
+func setEnv(c *corev1.Container, key, value string) {
+	for i := range c.Env {
+		if c.Env[i].Name == key {
+			c.Env[i] = corev1.EnvVar{Name: key, Value: value}
+			return
+		}
+	}
+	c.Env = append(c.Env, corev1.EnvVar{Name: key, Value: value})
+}
+
+// selectContainer returns the container specified by -container.
+// The name can be empty when the pod has only one container.
+func selectContainer(podSpec *corev1.PodSpec, name string) (*corev1.Container, error) {
+	if name != "" {
+		return findPodContainer(podSpec, name)
+	}
+	if len(podSpec.Containers) != 1 {
+		return nil, fmt.Errorf("-container is required because the pod has %d containers", len(podSpec.Containers))
+	}
+	return &podSpec.Containers[0], nil
+}
+
+// setImage sets the image by "container=image", or "image" when the pod has only one container.
+func setImage(podSpec *corev1.PodSpec, value string) error {
+	name, image, ok := strings.Cut(value, "=")
+	if !ok {
+		if len(podSpec.Containers) != 1 {
+			return fmt.Errorf("-image %q should be container=image because the pod has %d containers", value, len(podSpec.Containers))
+		}
+		podSpec.Containers[0].Image = value
+		return nil
+	}
+
+	c, err := findPodContainer(podSpec, name)
+	if err != nil {
+		return err
+	}
+	c.Image = image
+	return nil
+}
+
+// initContainerPrefix selects an init container, e.g. -container=init:migrate.
+const initContainerPrefix = "init:"
+
+// findPodContainer finds the container by name, or the init container by "init:name".
+func findPodContainer(podSpec *corev1.PodSpec, name string) (*corev1.Container, error) {
+	if initName, ok := strings.CutPrefix(name, initContainerPrefix); ok {
+		c, err := findContainer(podSpec.InitContainers, initName)
+		if err != nil {
+			return nil, fmt.Errorf("init %w", err)
+		}
+		return c, nil
+	}
+	return findContainer(podSpec.Containers, name)
+}
+
+func findContainer(containers []corev1.Container, name string) (*corev1.Container, error) {
+	names := make([]string, 0, len(containers))
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i], nil
+		}
+		names = append(names, containers[i].Name)
+	}
+	return nil, fmt.Errorf("container %q is not found (available: %s)", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestJobOptions_Apply_SourceCommit(t *testing.T) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{"foo": "bar"},
+		},
+	}
+
+	opts := jobOptions{sourceCommit: "abc123"}
+	if err := opts.apply(job); err != nil {
+		t.Fatalf("apply got error: %v", err)
+	}
+
+	expect := map[string]string{
+		"foo":                  "bar",
+		sourceCommitAnnotation: "abc123",
+	}
+	if diff := cmp.Diff(expect, job.Annotations); diff != "" {
+		t.Errorf("annotations diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestJobOptions_Apply_AnnotationsAndLabels(t *testing.T) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{"owner": "batch-team", "reason": "scheduled"},
+			Labels:      map[string]string{"app": "report"},
+		},
+	}
+
+	opts := jobOptions{
+		annotations: keyValueFlag{"reason=rerun", "triggered-by=alice"},
+		labels:      keyValueFlag{"app=report-debug", "manual=true"},
+	}
+	if err := opts.apply(job); err != nil {
+		t.Fatalf("apply got error: %v", err)
+	}
+
+	expectAnnotations := map[string]string{"owner": "batch-team", "reason": "rerun", "triggered-by": "alice"}
+	if diff := cmp.Diff(expectAnnotations, job.Annotations); diff != "" {
+		t.Errorf("annotations diff (-expect, +got)\n%s", diff)
+	}
+	expectLabels := map[string]string{"app": "report-debug", "manual": "true"}
+	if diff := cmp.Diff(expectLabels, job.Labels); diff != "" {
+		t.Errorf("labels diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestJobOptions_Apply_Images(t *testing.T) {
+	tests := map[string]struct {
+		containers []corev1.Container
+		images     []string
+		expect     []corev1.Container
+		wantErr    bool
+	}{
+		"single container shorthand": {
+			containers: []corev1.Container{{Name: "app", Image: "app:v1"}},
+			images:     []string{"app:v2"},
+			expect:     []corev1.Container{{Name: "app", Image: "app:v2"}},
+		},
+		"name=image form": {
+			containers: []corev1.Container{{Name: "app", Image: "app:v1"}, {Name: "sidecar", Image: "sidecar:v1"}},
+			images:     []string{"sidecar=sidecar:v2"},
+			expect:     []corev1.Container{{Name: "app", Image: "app:v1"}, {Name: "sidecar", Image: "sidecar:v2"}},
+		},
+		"shorthand with multiple containers": {
+			containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}},
+			images:     []string{"app:v2"},
+			wantErr:    true,
+		},
+		"container doesn't exist": {
+			containers: []corev1.Container{{Name: "app"}},
+			images:     []string{"missing=app:v2"},
+			wantErr:    true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			job := &batchv1.Job{}
+			job.Spec.Template.Spec.Containers = tt.containers
+
+			err := jobOptions{images: tt.images}.apply(job)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.expect, job.Spec.Template.Spec.Containers); diff != "" {
+				t.Errorf("containers diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestJobOptions_Apply_InitContainer(t *testing.T) {
+	job := &batchv1.Job{}
+	podSpec := &job.Spec.Template.Spec
+	podSpec.InitContainers = []corev1.Container{{Name: "migrate", Image: "migrate:v1"}}
+	podSpec.Containers = []corev1.Container{{Name: "migrate", Image: "app:v1"}}
+
+	opts := jobOptions{
+		images:    stringsFlag{"init:migrate=migrate:v2"},
+		container: "init:migrate",
+		command:   stringsFlag{"migrate", "--dry-run"},
+	}
+	if err := opts.apply(job); err != nil {
+		t.Fatalf("apply got error: %v", err)
+	}
+
+	expectInit := []corev1.Container{{Name: "migrate", Image: "migrate:v2", Command: []string{"migrate", "--dry-run"}}}
+	if diff := cmp.Diff(expectInit, podSpec.InitContainers); diff != "" {
+		t.Errorf("initContainers diff (-expect, +got)\n%s", diff)
+	}
+	expect := []corev1.Container{{Name: "migrate", Image: "app:v1"}}
+	if diff := cmp.Diff(expect, podSpec.Containers); diff != "" {
+		t.Errorf("containers diff (-expect, +got)\n%s", diff)
+	}
+
+	err := jobOptions{images: stringsFlag{"init:missing=app:v2"}}.apply(job)
+	expectErr := `init container "missing" is not found (available: migrate)`
+	if err == nil || err.Error() != expectErr {
+		t.Errorf(`apply error expected "%s", got %v`, expectErr, err)
+	}
+}
+
+func TestJobOptions_Apply_Command(t *testing.T) {
+	tests := map[string]struct {
+		opts       jobOptions
+		containers []corev1.Container
+		expect     []corev1.Container
+		wantErr    bool
+	}{
+		"command and args are replaced": {
+			opts: jobOptions{command: stringsFlag{"sh", "-c"}, args: stringsFlag{"echo debug"}},
+			containers: []corev1.Container{
+				{Name: "app", Image: "app:v1", Command: []string{"app"}, Args: []string{"run"}, WorkingDir: "/app"},
+			},
+			expect: []corev1.Container{
+				{Name: "app", Image: "app:v1", Command: []string{"sh", "-c"}, Args: []string{"echo debug"}, WorkingDir: "/app"},
+			},
+		},
+		"only command is replaced": {
+			opts: jobOptions{command: stringsFlag{"sleep", "3600"}},
+			containers: []corev1.Container{
+				{Name: "app", Command: []string{"app"}, Args: []string{"run"}},
+			},
+			expect: []corev1.Container{
+				{Name: "app", Command: []string{"sleep", "3600"}, Args: []string{"run"}},
+			},
+		},
+		"container is selected": {
+			opts: jobOptions{container: "sidecar", command: stringsFlag{"sleep"}},
+			containers: []corev1.Container{
+				{Name: "app", Command: []string{"app"}},
+				{Name: "sidecar", Command: []string{"proxy"}},
+			},
+			expect: []corev1.Container{
+				{Name: "app", Command: []string{"app"}},
+				{Name: "sidecar", Command: []string{"sleep"}},
+			},
+		},
+		"container is required for multiple containers": {
+			opts:       jobOptions{command: stringsFlag{"sleep"}},
+			containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}},
+			wantErr:    true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			job := &batchv1.Job{}
+			job.Spec.Template.Spec.Containers = tt.containers
+
+			err := tt.opts.apply(job)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.expect, job.Spec.Template.Spec.Containers); diff != "" {
+				t.Errorf("containers diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestJobOptions_Apply_Env(t *testing.T) {
+	job := &batchv1.Job{}
+	job.Spec.Template.Spec.Containers = []corev1.Container{
+		{
+			Name: "app",
+			Env: []corev1.EnvVar{
+				{Name: "RUN_DATE", Value: "2024-01-01"},
+				{Name: "SECRET", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "key"}}},
+			},
+		},
+	}
+
+	opts := jobOptions{env: keyValueFlag{"RUN_DATE=2024-02-01", "FEATURE=on"}}
+	if err := opts.apply(job); err != nil {
+		t.Fatalf("apply got error: %v", err)
+	}
+
+	expect := []corev1.EnvVar{
+		{Name: "RUN_DATE", Value: "2024-02-01"},
+		{Name: "SECRET", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "key"}}},
+		{Name: "FEATURE", Value: "on"},
+	}
+	if diff := cmp.Diff(expect, job.Spec.Template.Spec.Containers[0].Env); diff != "" {
+		t.Errorf("env diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestJobOptions_Apply_AllContainers(t *testing.T) {
+	job := &batchv1.Job{}
+	job.Spec.Template.Spec.Containers = []corev1.Container{
+		{Name: "app", Image: "app:v1", Env: []corev1.EnvVar{{Name: "DEBUG", Value: "false"}}},
+		{Name: "sidecar", Image: "sidecar:v1"},
+	}
+
+	opts := jobOptions{allContainers: true, env: keyValueFlag{"DEBUG=true"}, images: stringsFlag{"debug:v1"}}
+	if err := opts.apply(job); err != nil {
+		t.Fatalf("apply got error: %v", err)
+	}
+
+	expect := []corev1.Container{
+		{Name: "app", Image: "debug:v1", Env: []corev1.EnvVar{{Name: "DEBUG", Value: "true"}}},
+		{Name: "sidecar", Image: "debug:v1", Env: []corev1.EnvVar{{Name: "DEBUG", Value: "true"}}},
+	}
+	if diff := cmp.Diff(expect, job.Spec.Template.Spec.Containers); diff != "" {
+		t.Errorf("containers diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestJobOptions_Apply_ParallelismAndCompletions(t *testing.T) {
+	job := &batchv1.Job{Spec: batchv1.JobSpec{Parallelism: toPtr(int32(1)), Completions: toPtr(int32(1))}}
+
+	opts := jobOptions{parallelism: 3, completions: 5}
+	if err := opts.apply(job); err != nil {
+		t.Fatalf("apply got error: %v", err)
+	}
+	if got := *job.Spec.Parallelism; got != 3 {
+		t.Errorf("parallelism expected 3, got %d", got)
+	}
+	if got := *job.Spec.Completions; got != 5 {
+		t.Errorf("completions expected 5, got %d", got)
+	}
+
+	// Zero keeps the template value.
+	if err := (jobOptions{}).apply(job); err != nil {
+		t.Fatalf("apply got error: %v", err)
+	}
+	if got := *job.Spec.Parallelism; got != 3 {
+		t.Errorf("parallelism expected to be kept, got %d", got)
+	}
+}
+
+func TestJobOptions_Validate(t *testing.T) {
+	tests := map[string]struct {
+		opts    jobOptions
+		wantErr bool
+	}{
+		"not set": {
+			opts: jobOptions{},
+		},
+		"parallelism only": {
+			opts: jobOptions{parallelism: 3},
+		},
+		"parallelism equals completions": {
+			opts: jobOptions{parallelism: 3, completions: 3},
+		},
+		"parallelism exceeds completions": {
+			opts:    jobOptions{parallelism: 4, completions: 3},
+			wantErr: true,
+		},
+		"negative value": {
+			opts:    jobOptions{completions: -1},
+			wantErr: true,
+		},
+		"restart policy Never": {
+			opts: jobOptions{restartPolicy: "Never"},
+		},
+		"restart policy OnFailure": {
+			opts: jobOptions{restartPolicy: "OnFailure"},
+		},
+		"restart policy Always is invalid for Job": {
+			opts:    jobOptions{restartPolicy: "Always"},
+			wantErr: true,
+		},
+		"unknown restart policy": {
+			opts:    jobOptions{restartPolicy: "never"},
+			wantErr: true,
+		},
+		"image pull policy": {
+			opts: jobOptions{imagePullPolicy: "Always"},
+		},
+		"unknown image pull policy": {
+			opts:    jobOptions{imagePullPolicy: "always"},
+			wantErr: true,
+		},
+		"annotation and label": {
+			opts: jobOptions{annotations: keyValueFlag{"kj.kitagry.dev/reason=rerun failed batch"}, labels: keyValueFlag{"triggered-by=alice"}},
+		},
+		"invalid annotation key": {
+			opts:    jobOptions{annotations: keyValueFlag{"not a key=value"}},
+			wantErr: true,
+		},
+		"invalid label value": {
+			opts:    jobOptions{labels: keyValueFlag{"reason=rerun failed batch"}},
+			wantErr: true,
+		},
+		"target namespace": {
+			opts: jobOptions{targetNamespace: "sandbox"},
+		},
+		"invalid target namespace": {
+			opts:    jobOptions{targetNamespace: "Sandbox"},
+			wantErr: true,
+		},
+		"all containers": {
+			opts: jobOptions{allContainers: true, images: stringsFlag{"app:v2"}},
+		},
+		"all containers with container": {
+			opts:    jobOptions{allContainers: true, container: "app"},
+			wantErr: true,
+		},
+		"all containers with container=image": {
+			opts:    jobOptions{allContainers: true, images: stringsFlag{"app=app:v2"}},
+			wantErr: true,
+		},
+		"service account": {
+			opts: jobOptions{serviceAccount: "debug-reader"},
+		},
+		"service account is not a DNS-1123 label": {
+			opts:    jobOptions{serviceAccount: "Debug_Reader"},
+			wantErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			err := tt.opts.validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJobOptions_Apply_RestartPolicy(t *testing.T) {
+	job := &batchv1.Job{}
+	job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
+
+	if err := (jobOptions{restartPolicy: "Never"}).apply(job); err != nil {
+		t.Fatalf("apply got error: %v", err)
+	}
+	if got := job.Spec.Template.Spec.RestartPolicy; got != corev1.RestartPolicyNever {
+		t.Errorf("restartPolicy expected Never, got %s", got)
+	}
+}
+
+func TestJobOptions_Apply_NodeSelector(t *testing.T) {
+	job := &batchv1.Job{}
+	job.Spec.Template.Spec.NodeSelector = map[string]string{"pool": "batch", "zone": "a"}
+
+	opts := jobOptions{nodeSelector: keyValueFlag{"zone=b", "disk=ssd"}}
+	if err := opts.apply(job); err != nil {
+		t.Fatalf("apply got error: %v", err)
+	}
+
+	expect := map[string]string{"pool": "batch", "zone": "b", "disk": "ssd"}
+	if diff := cmp.Diff(expect, job.Spec.Template.Spec.NodeSelector); diff != "" {
+		t.Errorf("nodeSelector diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestJobOptions_Apply_NodeName(t *testing.T) {
+	job := &batchv1.Job{}
+
+	if err := (jobOptions{nodeName: "node-1"}).apply(job); err != nil {
+		t.Fatalf("apply got error: %v", err)
+	}
+	if got := job.Spec.Template.Spec.NodeName; got != "node-1" {
+		t.Errorf(`nodeName expected "node-1", got "%s"`, got)
+	}
+}
+
+func TestJobOptions_Apply_ServiceAccount(t *testing.T) {
+	job := &batchv1.Job{}
+	job.Spec.Template.Spec.ServiceAccountName = "batch"
+
+	if err := (jobOptions{serviceAccount: "debug-reader"}).apply(job); err != nil {
+		t.Fatalf("apply got error: %v", err)
+	}
+	if got := job.Spec.Template.Spec.ServiceAccountName; got != "debug-reader" {
+		t.Errorf(`serviceAccountName expected "debug-reader", got "%s"`, got)
+	}
+}
+
+func TestJobOptions_Apply_EnvFrom(t *testing.T) {
+	job := &batchv1.Job{}
+	job.Spec.Template.Spec.Containers = []corev1.Container{
+		{
+			Name: "app",
+			EnvFrom: []corev1.EnvFromSource{
+				{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "base"}}},
+			},
+		},
+	}
+
+	opts := jobOptions{envFrom: stringsFlag{"configmap:debug", "secret:credentials"}}
+	if err := opts.apply(job); err != nil {
+		t.Fatalf("apply got error: %v", err)
+	}
+
+	expect := []corev1.EnvFromSource{
+		{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "base"}}},
+		{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "debug"}}},
+		{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "credentials"}}},
+	}
+	if diff := cmp.Diff(expect, job.Spec.Template.Spec.Containers[0].EnvFrom); diff != "" {
+		t.Errorf("envFrom diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestJobOptions_Apply_ImagePullPolicy(t *testing.T) {
+	job := &batchv1.Job{}
+	job.Spec.Template.Spec.Containers = []corev1.Container{
+		{Name: "app", ImagePullPolicy: corev1.PullIfNotPresent},
+		{Name: "sidecar", ImagePullPolicy: corev1.PullIfNotPresent},
+	}
+
+	opts := jobOptions{imagePullPolicy: "Always", container: "sidecar"}
+	if err := opts.apply(job); err != nil {
+		t.Fatalf("apply got error: %v", err)
+	}
+
+	containers := job.Spec.Template.Spec.Containers
+	if got := containers[1].ImagePullPolicy; got != corev1.PullAlways {
+		t.Errorf(`imagePullPolicy of sidecar expected "Always", got "%s"`, got)
+	}
+	if got := containers[0].ImagePullPolicy; got != corev1.PullIfNotPresent {
+		t.Errorf(`imagePullPolicy of app should not be changed, got "%s"`, got)
+	}
+}
+
+func TestParseEnvFrom_Invalid(t *testing.T) {
+	for _, s := range []string{"configmap", "secret:", "volume:data"} {
+		if _, err := parseEnvFrom(s); err == nil {
+			t.Errorf("parseEnvFrom(%q) should return error", s)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-tty"
+	"github.com/mattn/go-tty/ttyutil"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// selectCronJob returns the name of the only CronJob which matches the label selector.
+func selectCronJob(ctx context.Context, clientset kubernetes.Interface, ga bool, namespace, selector string) (string, error) {
+	cronJobs, err := listCronJobs(ctx, clientset, ga, namespace, selector)
+	if err != nil {
+		return "", err
+	}
+
+	switch len(cronJobs) {
+	case 0:
+		return "", fmt.Errorf("no cronjob matches %q in namespace %s", selector, namespace)
+	case 1:
+		return cronJobs[0].Name, nil
+	}
+	names := make([]string, 0, len(cronJobs))
+	for _, cj := range cronJobs {
+		names = append(names, cj.Name)
+	}
+	return "", fmt.Errorf("%d cronjobs match %q in namespace %s: %s", len(cronJobs), selector, namespace, strings.Join(names, ", "))
+}
+
+// pickCronJob lets the user select the CronJob in the namespace by its number.
+func pickCronJob(ctx context.Context, clientset kubernetes.Interface, ga bool, tty *tty.TTY, namespace string) (string, error) {
+	cronJobs, err := listCronJobs(ctx, clientset, ga, namespace, "")
+	if err != nil {
+		return "", err
+	}
+	if len(cronJobs) == 0 {
+		return "", fmt.Errorf("no cronjob is found in namespace %s", namespace)
+	}
+
+	printCronJobChoices(tty.Output(), cronJobs)
+	for {
+		fmt.Fprintf(tty.Output(), "Select a cronjob [1-%d]: ", len(cronJobs))
+		answer, err := ttyutil.ReadLine(tty)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return "", errors.New("no cronjob is selected")
+			}
+			return "", err
+		}
+		fmt.Fprintln(tty.Output())
+
+		i, err := parseSelection(answer, len(cronJobs))
+		if err != nil {
+			fmt.Fprintln(tty.Output(), err)
+			continue
+		}
+		return cronJobs[i].Name, nil
+	}
+}
+
+func printCronJobChoices(w io.Writer, cronJobs []batchv1.CronJob) {
+	for i, cj := range cronJobs {
+		fmt.Fprintf(w, "%3d) %s\t%s\n", i+1, cj.Name, cj.Spec.Schedule)
+	}
+}
+
+// parseSelection returns the 0-based index selected by the 1-based answer.
+func parseSelection(answer string, n int) (int, error) {
+	i, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || i < 1 || i > n {
+		return 0, fmt.Errorf("please enter a number between 1 and %d", n)
+	}
+	return i - 1, nil
+}
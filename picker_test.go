@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := map[string]struct {
+		answer  string
+		expect  int
+		wantErr bool
+	}{
+		"first":        {answer: "1", expect: 0},
+		"last":         {answer: " 3\n", expect: 2},
+		"zero":         {answer: "0", wantErr: true},
+		"out of range": {answer: "4", wantErr: true},
+		"not a number": {answer: "a", wantErr: true},
+		"empty":        {answer: "", wantErr: true},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got, err := parseSelection(tt.answer, 3)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSelection error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if got != tt.expect {
+				t.Errorf("parseSelection expected %d, got %d", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestPrintCronJobChoices(t *testing.T) {
+	cronJobs := []batchv1.CronJob{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a"}, Spec: batchv1.CronJobSpec{Schedule: "0 * * * *"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b"}, Spec: batchv1.CronJobSpec{Schedule: "0 0 * * *"}},
+	}
+
+	var buf bytes.Buffer
+	printCronJobChoices(&buf, cronJobs)
+
+	expect := "  1) a\t0 * * * *\n  2) b\t0 0 * * *\n"
+	if diff := cmp.Diff(expect, buf.String()); diff != "" {
+		t.Errorf("printCronJobChoices result diff (-expect, +got)\n%s", diff)
+	}
+}
+
+func TestSelectCronJob(t *testing.T) {
+	newCronJob := func(name string, labels map[string]string) *batchv1.CronJob {
+		return &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels}}
+	}
+	clientset := newFakeClientset("1", "29",
+		newCronJob("report", map[string]string{"app": "report", "team": "data"}),
+		newCronJob("export", map[string]string{"app": "export", "team": "data"}),
+	)
+
+	tests := map[string]struct {
+		selector string
+		expect   string
+		wantErr  bool
+	}{
+		"single match": {
+			selector: "app=report",
+			expect:   "report",
+		},
+		"multiple match": {
+			selector: "team=data",
+			wantErr:  true,
+		},
+		"no match": {
+			selector: "app=missing",
+			wantErr:  true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got, err := selectCronJob(context.Background(), clientset, true, "default", tt.selector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectCronJob error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if got != tt.expect {
+				t.Errorf(`selectCronJob expected "%s", got "%s"`, tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// validatePortForward checks that s is LOCAL_PORT:POD_PORT.
+func validatePortForward(s string) error {
+	local, remote, ok := strings.Cut(s, ":")
+	if !ok {
+		return fmt.Errorf("port-forward %q should be LOCAL_PORT:POD_PORT format", s)
+	}
+	for _, p := range []string{local, remote} {
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 || n > 65535 {
+			return fmt.Errorf("port-forward %q has invalid port %q", s, p)
+		}
+	}
+	return nil
+}
+
+// portForwardJob waits for the pod of the Job to be running, then forwards ports until ctx is done.
+func portForwardJob(ctx context.Context, config *rest.Config, clientset kubernetes.Interface, namespace, jobName, ports string) error {
+	pod, err := waitForJobPod(ctx, clientset, namespace, jobName)
+	if err != nil {
+		return fmt.Errorf("failed to find running pod for job %s: %w", jobName, err)
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return err
+	}
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod.Name).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	stopCh := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		close(stopCh)
+	}()
+
+	fw, err := portforward.New(dialer, []string{ports}, stopCh, nil, os.Stdout, os.Stderr)
+	if err != nil {
+		return err
+	}
+	return fw.ForwardPorts()
+}
+
+// waitForJobPod waits for the running pod of the Job. It fails when the Job finishes first,
+// e.g. the pod completes or fails before it is seen running.
+func waitForJobPod(ctx context.Context, clientset kubernetes.Interface, namespace, jobName string) (pod *corev1.Pod, err error) {
+	err = wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (bool, error) {
+		pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: jobNameLabel + "=" + jobName,
+		})
+		if err != nil {
+			return false, err
+		}
+		pod = runningPod(pods.Items)
+		if pod != nil {
+			return true, nil
+		}
+
+		job, err := clientset.BatchV1().Jobs(namespace).Get(ctx, jobName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if jobFinished(job) {
+			return false, fmt.Errorf("job %s finished before its pod was running", jobName)
+		}
+		return false, nil
+	})
+	return pod, err
+}
+
+func runningPod(pods []corev1.Pod) *corev1.Pod {
+	for i := range pods {
+		if pods[i].Status.Phase == corev1.PodRunning {
+			return &pods[i]
+		}
+	}
+	return nil
+}
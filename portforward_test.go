@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidatePortForward(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		wantErr bool
+	}{
+		"valid":            {input: "8080:80"},
+		"no separator":     {input: "8080", wantErr: true},
+		"not a number":     {input: "http:80", wantErr: true},
+		"port is too big":  {input: "8080:70000", wantErr: true},
+		"port is zero":     {input: "0:80", wantErr: true},
+		"remote is absent": {input: "8080:", wantErr: true},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			err := validatePortForward(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validatePortForward(%q) error = %v, wantErr %t", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunningPod(t *testing.T) {
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pending"}, Status: corev1.PodStatus{Phase: corev1.PodPending}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "running"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+	}
+
+	got := runningPod(pods)
+	if got == nil || got.Name != "running" {
+		t.Errorf("runningPod expected running pod, got %v", got)
+	}
+
+	if got := runningPod(pods[:1]); got != nil {
+		t.Errorf("runningPod expected nil, got %s", got.Name)
+	}
+}
+
+func TestWaitForJobPod(t *testing.T) {
+	job := func(conditions ...batchv1.JobCondition) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-abcdef"},
+			Status:     batchv1.JobStatus{Conditions: conditions},
+		}
+	}
+	pod := func(phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-abcdef-x7k2p", Labels: map[string]string{jobNameLabel: "test-abcdef"}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	tests := map[string]struct {
+		objects []runtime.Object
+		expect  string
+		wantErr bool
+	}{
+		"running pod": {
+			objects: []runtime.Object{job(), pod(corev1.PodRunning)},
+			expect:  "test-abcdef-x7k2p",
+		},
+		"job completed before the pod was running": {
+			objects: []runtime.Object{
+				job(batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}),
+				pod(corev1.PodSucceeded),
+			},
+			wantErr: true,
+		},
+		"job failed without pods": {
+			objects: []runtime.Object{job(batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue})},
+			wantErr: true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(tt.objects...)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			got, err := waitForJobPod(ctx, clientset, "default", "test-abcdef")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("waitForJobPod should return error when the job finished")
+				}
+				if ctx.Err() != nil {
+					t.Errorf("waitForJobPod should fail before the timeout, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("waitForJobPod got error: %v", err)
+			}
+			if got.Name != tt.expect {
+				t.Errorf(`pod expected "%s", got "%s"`, tt.expect, got.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// manifestFileName returns "<namespace>-<name>-<timestamp>.yaml" for the applied manifest.
+func manifestFileName(namespace, name string, now time.Time) string {
+	return fmt.Sprintf("%s-%s-%s.yaml", namespace, name, now.UTC().Format("20060102T150405Z"))
+}
+
+// prepareSaveDir creates dir if missing and checks the manifest can be saved there.
+// It is called before applying so that a bad -save-dir doesn't fail after the Job is created.
+func prepareSaveDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create -save-dir: %w", err)
+	}
+	if err := checkWritableDir(dir); err != nil {
+		return fmt.Errorf("-save-dir is not usable: %w", err)
+	}
+	return nil
+}
+
+// saveManifest copies the applied manifest in src into dir prepared by prepareSaveDir.
+// It returns the path of the saved file.
+func saveManifest(dir, src, namespace, name string, now time.Time) (string, error) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, manifestFileName(namespace, name, now))
+	f, err := createPrivateFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to save the manifest: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to save the manifest: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to save the manifest: %w", err)
+	}
+	return path, nil
+}
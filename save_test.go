@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManifestFileName(t *testing.T) {
+	now := time.Date(2024, 4, 1, 12, 34, 56, 0, time.FixedZone("JST", 9*60*60))
+	got := manifestFileName("default", "test-abc123", now)
+	expect := "default-test-abc123-20240401T033456Z.yaml"
+	if got != expect {
+		t.Errorf(`manifestFileName expected "%s", got "%s"`, expect, got)
+	}
+}
+
+func TestSaveManifest(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "job.yaml")
+	if err := os.WriteFile(src, []byte("kind: Job\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "backup", "jobs")
+	if err := prepareSaveDir(dir); err != nil {
+		t.Fatalf("prepareSaveDir got error: %v", err)
+	}
+	now := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	path, err := saveManifest(dir, src, "default", "test-abc123", now)
+	if err != nil {
+		t.Fatalf("saveManifest got error: %v", err)
+	}
+	if expect := filepath.Join(dir, "default-test-abc123-20240401T120000Z.yaml"); path != expect {
+		t.Errorf(`saved path expected "%s", got "%s"`, expect, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "kind: Job\n" {
+		t.Errorf("saved manifest expected to be the applied one, got %q", data)
+	}
+}
+
+func TestSaveManifest_NotWritable(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "job.yaml")
+	if err := os.WriteFile(src, []byte("kind: Job\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// A regular file can't be used as the directory.
+	dir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(dir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := prepareSaveDir(dir); err == nil {
+		t.Error("prepareSaveDir should return error when the directory is not writable")
+	}
+	if _, err := saveManifest(dir, src, "default", "test", time.Now()); err == nil {
+		t.Error("saveManifest should return error when the directory is not writable")
+	}
+}
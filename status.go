@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	statusPollInterval = time.Second
+	statusPollCount    = 5
+)
+
+// pollJobStatus returns the status of the Job. It polls a few times until the Job is started
+// because the status is empty just after the Job is created.
+func pollJobStatus(ctx context.Context, clientset kubernetes.Interface, namespace, name string) (status batchv1.JobStatus, err error) {
+	for i := 0; i < statusPollCount; i++ {
+		job, err := clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return status, fmt.Errorf("failed to get job status: %w", err)
+		}
+		status = job.Status
+		if status.StartTime != nil {
+			return status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return status, nil
+		case <-time.After(statusPollInterval):
+		}
+	}
+	return status, nil
+}
+
+func formatJobStatus(status batchv1.JobStatus) string {
+	started := "<pending>"
+	if status.StartTime != nil {
+		started = status.StartTime.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("active=%d succeeded=%d failed=%d started=%s", status.Active, status.Succeeded, status.Failed, started)
+}
+
+const jobWaitInterval = 2 * time.Second
+
+var errWaitTimeout = errors.New("timed out waiting for job to complete")
+
+// waitForJob waits until the Job completes or fails. timeout 0 means waiting until ctx is done.
+// It returns an error when the Job failed.
+func waitForJob(ctx context.Context, clientset kubernetes.Interface, namespace, name string, timeout time.Duration) (*batchv1.Job, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var job *batchv1.Job
+	err := wait.PollUntilContextCancel(ctx, jobWaitInterval, true, func(ctx context.Context) (bool, error) {
+		var err error
+		job, err = clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to get job status: %w", err)
+		}
+		return jobFinished(job), nil
+	})
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, errWaitTimeout
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return job, fmt.Errorf("job %s failed: %s", name, c.Message)
+		}
+	}
+	return job, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFormatJobStatus(t *testing.T) {
+	tests := map[string]struct {
+		status batchv1.JobStatus
+		expect string
+	}{
+		"started": {
+			status: batchv1.JobStatus{
+				Active:    1,
+				Succeeded: 2,
+				Failed:    3,
+				StartTime: &metav1.Time{Time: time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)},
+			},
+			expect: "active=1 succeeded=2 failed=3 started=2024-04-01T12:00:00Z",
+		},
+		"not started": {
+			status: batchv1.JobStatus{},
+			expect: "active=0 succeeded=0 failed=0 started=<pending>",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got := formatJobStatus(tt.status)
+			if got != tt.expect {
+				t.Errorf(`formatJobStatus expected "%s", got "%s"`, tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestPollJobStatus(t *testing.T) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Status: batchv1.JobStatus{
+			Active:    1,
+			StartTime: &metav1.Time{Time: time.Now()},
+		},
+	}
+	clientset := fake.NewSimpleClientset(job)
+
+	status, err := pollJobStatus(context.Background(), clientset, "default", "test")
+	if err != nil {
+		t.Fatalf("pollJobStatus got error: %v", err)
+	}
+	if status.Active != 1 {
+		t.Errorf("active expected 1, got %d", status.Active)
+	}
+	if len(clientset.Actions()) != 1 {
+		t.Errorf("pollJobStatus should return after the first get when the job is started, got %d actions", len(clientset.Actions()))
+	}
+}
+
+func TestWaitForJob(t *testing.T) {
+	tests := map[string]struct {
+		conditions []batchv1.JobCondition
+		expectErr  string
+	}{
+		"complete": {
+			conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+		},
+		"failed": {
+			conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "Job has reached the specified backoff limit"}},
+			expectErr:  "job test failed: Job has reached the specified backoff limit",
+		},
+		"never completes": {
+			expectErr: "timed out waiting for job to complete",
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+				Status:     batchv1.JobStatus{Conditions: tt.conditions},
+			}
+			clientset := fake.NewSimpleClientset(job)
+
+			_, err := waitForJob(context.Background(), clientset, "default", "test", 100*time.Millisecond)
+			if tt.expectErr == "" {
+				if err != nil {
+					t.Fatalf("waitForJob got error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expectErr {
+				t.Errorf(`waitForJob error expected "%s", got %v`, tt.expectErr, err)
+			}
+		})
+	}
+}
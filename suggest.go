@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+const maxSuggestions = 3
+
+// withSuggestions adds the similar CronJob names to the NotFound error.
+func withSuggestions(ctx context.Context, clientset kubernetes.Interface, ga bool, namespace, name string, err error) error {
+	cronJobs, listErr := listCronJobs(ctx, clientset, ga, namespace, "")
+	if listErr != nil {
+		return err
+	}
+
+	candidates := make([]string, 0, len(cronJobs))
+	for _, cj := range cronJobs {
+		candidates = append(candidates, cj.Name)
+	}
+	suggestions := suggestNames(name, candidates)
+	if len(suggestions) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w\n\ndid you mean %s?", err, strings.Join(suggestions, " or "))
+}
+
+// suggestNames returns the candidates close to name, from the closest one.
+func suggestNames(name string, candidates []string) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+
+	// Allow roughly one typo per three characters like kubectl.
+	threshold := max(2, len(name)/3)
+	var s []scored
+	for _, c := range candidates {
+		d := levenshtein(name, c)
+		if d <= threshold || strings.Contains(c, name) {
+			s = append(s, scored{name: c, distance: d})
+		}
+	}
+	sort.Slice(s, func(i, j int) bool {
+		if s[i].distance != s[j].distance {
+			return s[i].distance < s[j].distance
+		}
+		return s[i].name < s[j].name
+	})
+
+	names := make([]string, 0, maxSuggestions)
+	for i := 0; i < len(s) && i < maxSuggestions; i++ {
+		names = append(names, s[i].name)
+	}
+	return names
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
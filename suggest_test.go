@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSuggestNames(t *testing.T) {
+	candidates := []string{"daily-report", "daily-reports", "weekly-report", "cleanup", "daily-export"}
+
+	tests := map[string]struct {
+		name   string
+		expect []string
+	}{
+		"closest names come first": {
+			name:   "daily-reprot",
+			expect: []string{"daily-report", "daily-reports", "daily-export"},
+		},
+		"substring matches": {
+			name:   "clean",
+			expect: []string{"cleanup"},
+		},
+		"no similar names": {
+			name:   "migration",
+			expect: []string{},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			got := suggestNames(tt.name, candidates)
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Errorf("suggestNames diff (-expect, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		expect int
+	}{
+		{a: "", b: "abc", expect: 3},
+		{a: "abc", b: "abc", expect: 0},
+		{a: "kitten", b: "sitting", expect: 3},
+		{a: "report", b: "reprot", expect: 2},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.expect {
+			t.Errorf("levenshtein(%q, %q) expected %d, got %d", tt.a, tt.b, tt.expect, got)
+		}
+	}
+}
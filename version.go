@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// These are set with -ldflags "-X main.buildVersion=... -X main.buildCommit=... -X main.buildDate=...".
+var (
+	buildVersion = "dev"
+	buildCommit  = "none"
+	buildDate    = "unknown"
+)
+
+func formatVersion(version, commit, date string) string {
+	return fmt.Sprintf("%s version %s (commit: %s, built at: %s)", cmdName, version, commit, date)
+}
+
+// printVersion prints the version of kj, and the server version when the cluster is reachable.
+func printVersion(w io.Writer, config *rest.Config) {
+	v := buildVersion
+	if v == "dev" {
+		// installed by `go install`
+		if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			v = bi.Main.Version
+		}
+	}
+	fmt.Fprintln(w, formatVersion(v, buildCommit, buildDate))
+
+	if config == nil {
+		return
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return
+	}
+	if sv, err := clientset.Discovery().ServerVersion(); err == nil {
+		fmt.Fprintf(w, "Server Version: %s\n", sv.GitVersion)
+	}
+}
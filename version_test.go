@@ -0,0 +1,11 @@
+package main
+
+import "testing"
+
+func TestFormatVersion(t *testing.T) {
+	got := formatVersion("v1.2.3", "abc1234", "2024-04-01T00:00:00Z")
+	expect := "kj version v1.2.3 (commit: abc1234, built at: 2024-04-01T00:00:00Z)"
+	if got != expect {
+		t.Errorf(`formatVersion expected "%s", got "%s"`, expect, got)
+	}
+}